@@ -0,0 +1,116 @@
+package streamtagparser
+
+import (
+	"strings"
+	"unicode"
+)
+
+type attrState int
+
+const (
+	attrBetween attrState = iota
+	attrName
+	attrAfterName
+	attrAfterEq
+	attrQuotedValue
+	attrBareValue
+)
+
+// attrScanner splits the attributes of a start tag as they stream in. An attribute is a name,
+// optionally quoted, then = and a double quoted, single quoted or bare value; names without
+// a value are skipped
+type attrScanner struct {
+	state attrState
+	name  strings.Builder
+	value strings.Builder
+	quote rune
+}
+
+// writeRune returns the attribute that r completes, if any
+func (s *attrScanner) writeRune(r rune) (attr TagAttr, ok bool) {
+	space := unicode.IsSpace(r)
+	switch s.state {
+	case attrBetween:
+		if !space {
+			s.state = attrName
+			s.name.WriteRune(r)
+		}
+	case attrName:
+		switch {
+		case r == '=':
+			s.state = attrAfterEq
+		case space:
+			s.state = attrAfterName
+		default:
+			s.name.WriteRune(r)
+		}
+	case attrAfterName:
+		switch {
+		case r == '=':
+			s.state = attrAfterEq
+		case !space:
+			s.reset()
+			s.state = attrName
+			s.name.WriteRune(r)
+		}
+	case attrAfterEq:
+		switch {
+		case r == '"' || r == '\'':
+			s.state = attrQuotedValue
+			s.quote = r
+		case !space:
+			s.state = attrBareValue
+			s.value.WriteRune(r)
+		}
+	case attrQuotedValue:
+		if r == s.quote {
+			return s.complete()
+		}
+		s.value.WriteRune(r)
+	case attrBareValue:
+		if space {
+			return s.complete()
+		}
+		s.value.WriteRune(r)
+	}
+	return TagAttr{}, false
+}
+
+// finish returns the attribute left at the end of the start tag, if any
+func (s *attrScanner) finish() (attr TagAttr, ok bool) {
+	if s.state == attrBareValue || s.state == attrQuotedValue {
+		return s.complete()
+	}
+	s.reset()
+	return TagAttr{}, false
+}
+
+func (s *attrScanner) complete() (TagAttr, bool) {
+	attr := TagAttr{
+		Name:  strings.Trim(s.name.String(), `"'`),
+		Value: s.value.String(),
+	}
+	s.reset()
+	return attr, true
+}
+
+func (s *attrScanner) reset() {
+	s.state = attrBetween
+	s.name.Reset()
+	s.value.Reset()
+	s.quote = 0
+}
+
+// parseAttrs parses a complete attribute list
+func parseAttrs(s string) (attrs []TagAttr) {
+	var scanner attrScanner
+	for _, r := range s {
+		if attr, ok := scanner.writeRune(r); ok {
+			attrs = append(attrs, attr)
+		}
+	}
+	if attr, ok := scanner.finish(); ok {
+		attrs = append(attrs, attr)
+	}
+	return
+}
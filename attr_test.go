@@ -0,0 +1,22 @@
+package streamtagparser
+
+import "testing"
+
+func TestParseAttrs(t *testing.T) {
+	attrs := parseAttrs(` "id"=1 title="Tom & Jerry" lang='go' flag x = y last="open`)
+	expected := []TagAttr{
+		{Name: "id", Value: "1"},
+		{Name: "title", Value: "Tom & Jerry"},
+		{Name: "lang", Value: "go"},
+		{Name: "x", Value: "y"},
+		{Name: "last", Value: "open"},
+	}
+	if len(attrs) != len(expected) {
+		t.Fatalf("expected %d attrs, got: %+v", len(expected), attrs)
+	}
+	for i := range expected {
+		if attrs[i] != expected[i] {
+			t.Fatalf("expected attr: %+v, got: %+v", expected[i], attrs[i])
+		}
+	}
+}
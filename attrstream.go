@@ -0,0 +1,134 @@
+package streamtagparser
+
+import (
+	"html"
+	"strings"
+)
+
+// WithAttrEvents emits an attr event with each attribute of a start tag as soon as it is
+// complete, before the start event, so that a long attribute list shows up piece by piece
+func WithAttrEvents() Option {
+	return func(p *TagParser) {
+		p.attrEvents = true
+	}
+}
+
+// WithAttrDeltas streams the values of the attributes named attrNames as attr_delta events
+// while they are parsed, e.g. a long description. An attr_delta event carries the attribute
+// with the next piece of its value, the pieces add up to the value of the start event
+func WithAttrDeltas(attrNames ...string) Option {
+	return func(p *TagParser) {
+		if p.deltaAttrs == nil {
+			p.deltaAttrs = make(map[string]bool)
+		}
+		for _, name := range attrNames {
+			p.deltaAttrs[name] = true
+		}
+	}
+}
+
+// WithMaxAttrBytes sets how long the attributes of a start tag may get before it is taken
+// for text, 500 bytes by default
+func WithMaxAttrBytes(n int) Option {
+	return func(p *TagParser) {
+		p.maxAttrBytes = n
+	}
+}
+
+// scansAttrs reports whether an option needs the attributes as they stream in
+func (p *TagParser) scansAttrs() bool {
+	return p.pendingHints || p.attrEvents || len(p.deltaAttrs) > 0
+}
+
+// scanAttrRune feeds r of the attributes to the scanner, which parseAttrRune relies on to
+// tell quoted values, and emits what it completes
+func (p *TagParser) scanAttrRune(r rune) (tags []*TagStreamData) {
+	attr, ok := p.attrScan.writeRune(r)
+	if !p.scansAttrs() {
+		return nil
+	}
+	if !ok {
+		return p.attrDelta()
+	}
+	if p.decodeAttrEntities {
+		attr.Value = html.UnescapeString(attr.Value)
+	}
+	tags = p.completeAttr(attr)
+	return append(tags, p.hint()...)
+}
+
+// finishAttrs emits the attributes of a complete start tag that were not complete before its
+// end
+func (p *TagParser) finishAttrs(attrs []TagAttr) (tags []*TagStreamData) {
+	if !p.scansAttrs() {
+		return nil
+	}
+	for _, attr := range attrs[min(len(p.scannedAttrs), len(attrs)):] {
+		tags = append(tags, p.completeAttr(attr)...)
+	}
+	return
+}
+
+func (p *TagParser) completeAttr(attr TagAttr) (tags []*TagStreamData) {
+	p.scannedAttrs = append(p.scannedAttrs, attr)
+	if p.deltaAttrs[attr.Name] {
+		if len(attr.Value) > p.deltaSent {
+			tags = append(tags, p.newAttrDelta(attr.Name, attr.Value[p.deltaSent:]))
+		}
+		p.resetAttrDelta()
+	}
+	if p.attrEvents {
+		tags = append(tags, NewAttrTagStreamData(p.currentTagName, attr))
+	}
+	return
+}
+
+// attrDelta emits the next piece of the value being scanned if it is streamed
+func (p *TagParser) attrDelta() []*TagStreamData {
+	s := &p.attrScan
+	if s.state != attrQuotedValue && s.state != attrBareValue {
+		return nil
+	}
+	name := strings.Trim(s.name.String(), `"'`)
+	if !p.deltaAttrs[name] {
+		return nil
+	}
+	value := s.value.String()
+	end := len(value)
+	if s.state == attrBareValue {
+		// the end of the start tag ends a bare value, and is not part of it
+		end -= closePrefixLen(value, p.delimiters.Close)
+	}
+	if end <= p.deltaRaw {
+		return nil
+	}
+	delta := value[p.deltaRaw:end]
+	p.deltaRaw = end
+	if p.decodeAttrEntities {
+		if delta = p.deltaEntities.write(delta); delta == "" {
+			return nil
+		}
+	}
+	return []*TagStreamData{p.newAttrDelta(name, delta)}
+}
+
+func (p *TagParser) newAttrDelta(name, delta string) *TagStreamData {
+	p.deltaSent += len(delta)
+	return NewAttrDeltaTagStreamData(p.currentTagName, TagAttr{Name: name, Value: delta})
+}
+
+func (p *TagParser) resetAttrDelta() {
+	p.deltaRaw = 0
+	p.deltaSent = 0
+	p.deltaEntities.pending.Reset()
+}
+
+// closePrefixLen returns the length of the longest suffix of s that may begin close
+func closePrefixLen(s, close string) int {
+	for n := min(len(close)-1, len(s)); n > 0; n-- {
+		if strings.HasPrefix(close, s[len(s)-n:]) {
+			return n
+		}
+	}
+	return 0
+}
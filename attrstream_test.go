@@ -0,0 +1,144 @@
+package streamtagparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttrEvents(t *testing.T) {
+	t.Run("attributes as they complete", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact id="1" summary="Fix &amp;`,
+					expectedTags: []*TagStreamData{
+						{
+							Type:    TagStreamTypeAttr,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "id", Value: "1"}},
+						},
+						{
+							Type:    TagStreamTypeAttrDelta,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "summary", Value: "Fix &"}},
+						},
+					},
+				},
+				{
+					input: ` test" n=2>`,
+					expectedTags: []*TagStreamData{
+						{
+							Type:    TagStreamTypeAttrDelta,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "summary", Value: " test"}},
+						},
+						{
+							Type:    TagStreamTypeAttr,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "summary", Value: "Fix & test"}},
+						},
+						{
+							Type:    TagStreamTypeAttr,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "n", Value: "2"}},
+						},
+						{
+							Type:    TagStreamTypeStart,
+							TagName: "Artifact",
+							Attrs: []TagAttr{
+								{Name: "id", Value: "1"},
+								{Name: "summary", Value: "Fix & test"},
+								{Name: "n", Value: "2"},
+							},
+						},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{
+					Type:    TagStreamTypeEnd,
+					TagName: "Artifact",
+					Attrs: []TagAttr{
+						{Name: "id", Value: "1"},
+						{Name: "summary", Value: "Fix & test"},
+						{Name: "n", Value: "2"},
+					},
+				},
+			},
+		}
+		parser := NewTagParserWithOptions(
+			[]string{"Artifact"},
+			WithAttrEvents(),
+			WithAttrDeltas("summary"),
+			WithDecodeAttrEntities(),
+		)
+		testParser(t, testData, parser)
+	})
+
+	t.Run("bare value before a long delimiter", func(t *testing.T) {
+		parser := NewTagParserWithOptions(
+			[]string{"Artifact"},
+			WithDelimiters(SpecialTokenDelimiters),
+			WithAttrDeltas("summary"),
+		)
+		var deltas []string
+		for _, chunk := range []string{"<|Artifact summary=ab|", ">x"} {
+			for _, data := range parser.Parse(chunk) {
+				if data.Type == TagStreamTypeAttrDelta {
+					deltas = append(deltas, data.Attrs[0].Value)
+				}
+			}
+		}
+		if got := strings.Join(deltas, ","); got != "ab" {
+			t.Fatalf("expected deltas: ab, got: %s", got)
+		}
+	})
+
+	t.Run("delimiter in a quoted value", func(t *testing.T) {
+		attrs := []TagAttr{{Name: "summary", Value: "a -> b"}}
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact summary="a -`,
+					expectedTags: []*TagStreamData{
+						{
+							Type:    TagStreamTypeAttrDelta,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "summary", Value: "a -"}},
+						},
+					},
+				},
+				{
+					input: `> b">x`,
+					expectedTags: []*TagStreamData{
+						{
+							Type:    TagStreamTypeAttrDelta,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "summary", Value: "> b"}},
+						},
+						{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "x"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "Artifact", Attrs: attrs, Content: "x"},
+			},
+		}
+		testParser(t, testData, NewTagParserWithOptions(
+			[]string{"Artifact"},
+			WithAttrDeltas("summary"),
+		))
+	})
+
+	t.Run("max attr bytes", func(t *testing.T) {
+		long := `<Artifact summary="` + strings.Repeat("x", 600) + `">`
+		parser := NewTagParserWithOptions([]string{"Artifact"}, WithMaxAttrBytes(1000))
+		if got := parser.Parse(long); len(got) != 1 || got[0].Type != TagStreamTypeStart {
+			t.Fatalf("expected a start event, got: %v", got)
+		}
+		if got := NewTagParser("Artifact").Parse(long); got[0].Type != TagStreamTypeText {
+			t.Fatalf("expected text beyond the default limit, got: %s", got[0].Type)
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package streamtagparser
+
+const (
+	cdataOpen  = "<![CDATA["
+	cdataClose = "]]>"
+)
+
+var cdataCloseMarkers = []string{cdataClose}
+
+// WithCDATA passes <![CDATA[ ... ]]> sections in the content of tags through verbatim, an end
+// tag inside them does not close the tag
+func WithCDATA() Option {
+	return func(p *TagParser) {
+		p.cdata = true
+	}
+}
+
+// WithStripCDATA is WithCDATA, and removes the <![CDATA[ and ]]> wrapper from the content
+func WithStripCDATA() Option {
+	return func(p *TagParser) {
+		p.cdata = true
+		p.stripCDATA = true
+	}
+}
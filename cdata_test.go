@@ -0,0 +1,70 @@
+package streamtagparser
+
+import "testing"
+
+func TestCDATA(t *testing.T) {
+	t.Run("keep wrapper", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "<Artifact><![CDATA[<p></Artifact>]",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{
+							Type:    TagStreamTypeContent,
+							TagName: "Artifact",
+							Content: "<![CDATA[<p></Artifact>",
+						},
+					},
+				},
+				{
+					input: "]]]></Artifact>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "]]]]>"},
+						{
+							Type:    TagStreamTypeEnd,
+							TagName: "Artifact",
+							Content: "<![CDATA[<p></Artifact>]]]]>",
+						},
+					},
+				},
+			},
+		}
+		testParser(t, testData, NewTagParserWithOptions([]string{"Artifact"}, WithCDATA()))
+	})
+
+	t.Run("strip wrapper", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "<Artifact>a<![CDATA[</Artifact>]]>b<![CD",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a</Artifact>b"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "a</Artifact>b<![CD"},
+			},
+		}
+		testParser(t, testData, NewTagParserWithOptions([]string{"Artifact"}, WithStripCDATA()))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "<Artifact><![CDATA[</Artifact>]]>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "<![CDATA["},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "<![CDATA["},
+						{Type: TagStreamTypeText, Text: "]]>"},
+					},
+				},
+			},
+		}
+		testParserTest(t, testData, "Artifact")
+	})
+}
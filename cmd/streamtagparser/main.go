@@ -0,0 +1,328 @@
+// Command streamtagparser parses a text stream from stdin or a file and prints the events.
+//
+//	streamtagparser -tag Artifact -tag Think transcript.txt
+//	streamtagparser -tag Artifact -format pretty -random-chunks 8 -seed 42 < transcript.txt
+//	streamtagparser -tag Artifact -extract Artifact -out ./artifacts transcript.txt
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/liushuangls/streamtagparser"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "streamtagparser:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+type tagsFlag []string
+
+func (f *tagsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *tagsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+type options struct {
+	tags         tagsFlag
+	format       string
+	color        string
+	extract      string
+	out          string
+	chunkSize    int
+	randomChunks int
+	seed         uint64
+
+	ignoreMarkdownCode bool
+	codeBlocks         string
+	delimiters         string
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var opts options
+	fs := flag.NewFlagSet("streamtagparser", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: streamtagparser [flags] [file]")
+		fs.PrintDefaults()
+	}
+	fs.Var(&opts.tags, "tag", "tag `name` to parse, can be repeated")
+	fs.StringVar(&opts.format, "format", "jsonl", "output format: jsonl or pretty")
+	fs.StringVar(&opts.color, "color", "auto", "colourise pretty output: auto, always or never")
+	fs.StringVar(&opts.extract, "extract", "",
+		"print only the content of every `tag` with this name instead of the events")
+	fs.StringVar(&opts.out, "out", "", "with -extract, write each tag to a file in `dir`")
+	fs.IntVar(&opts.chunkSize, "chunk-size", 0, "re-chunk the input into `n` runes per chunk")
+	fs.IntVar(&opts.randomChunks, "random-chunks", 0,
+		"re-chunk the input into random sizes between 1 and `n` runes")
+	fs.Uint64Var(&opts.seed, "seed", 1, "seed for -random-chunks")
+	fs.BoolVar(&opts.ignoreMarkdownCode, "ignore-markdown-code", false,
+		"treat tags inside Markdown code spans and fences as text")
+	fs.StringVar(&opts.codeBlocks, "code-blocks", "",
+		"emit Markdown fenced code blocks as tags with this `name`")
+	fs.StringVar(&opts.delimiters, "delimiters", "angle",
+		"tag delimiters: angle, double-bracket, template or special-token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if opts.extract != "" && !slices.Contains(opts.tags, opts.extract) {
+		opts.tags = append(opts.tags, opts.extract)
+	}
+	if len(opts.tags) == 0 {
+		return errors.New("at least one -tag is required")
+	}
+	if opts.chunkSize > 0 && opts.randomChunks > 0 {
+		return errors.New("-chunk-size and -random-chunks cannot be combined")
+	}
+
+	input := stdin
+	if fs.NArg() > 0 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	out, err := newOutput(opts, stdout)
+	if err != nil {
+		return err
+	}
+
+	parserOpts, err := opts.parserOptions()
+	if err != nil {
+		return err
+	}
+	parser := streamtagparser.NewTagParserWithOptions(opts.tags, parserOpts...)
+	err = readChunks(input, opts, func(chunk string) error {
+		return out.write(parser.Parse(chunk))
+	})
+	if err != nil {
+		return err
+	}
+	return out.write(parser.ParseDone())
+}
+
+var delimiters = map[string]streamtagparser.Delimiters{
+	"angle":          streamtagparser.AngleDelimiters,
+	"double-bracket": streamtagparser.DoubleBracketDelimiters,
+	"template":       streamtagparser.TemplateDelimiters,
+	"special-token":  streamtagparser.SpecialTokenDelimiters,
+}
+
+func (opts options) parserOptions() (parserOpts []streamtagparser.Option, err error) {
+	d, ok := delimiters[opts.delimiters]
+	if !ok {
+		return nil, fmt.Errorf("unknown delimiters %q", opts.delimiters)
+	}
+	parserOpts = append(parserOpts, streamtagparser.WithDelimiters(d))
+	if opts.ignoreMarkdownCode {
+		parserOpts = append(parserOpts, streamtagparser.WithIgnoreTagsInMarkdownCode())
+	}
+	if opts.codeBlocks != "" {
+		parserOpts = append(parserOpts, streamtagparser.WithCodeBlocks(opts.codeBlocks))
+	}
+	return parserOpts, nil
+}
+
+// readChunks calls fn with the input as it arrives, or re-chunked when requested.
+// Chunks never split a UTF-8 sequence
+func readChunks(r io.Reader, opts options, fn func(chunk string) error) error {
+	if opts.chunkSize > 0 || opts.randomChunks > 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		for _, chunk := range rechunk(string(data), opts) {
+			if err := fn(chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	buf := make([]byte, 4096)
+	var pending []byte
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			end := completeRunes(pending)
+			if end > 0 {
+				if err := fn(string(pending[:end])); err != nil {
+					return err
+				}
+				pending = append(pending[:0], pending[end:]...)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			if len(pending) > 0 {
+				return fn(string(pending))
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// completeRunes returns the length of the prefix of p that does not end in the middle of a
+// UTF-8 sequence
+func completeRunes(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if utf8.FullRune(p[i:]) {
+				return len(p)
+			}
+			return i
+		}
+	}
+	return len(p)
+}
+
+func rechunk(s string, opts options) (chunks []string) {
+	runes := []rune(s)
+	rnd := rand.New(rand.NewPCG(opts.seed, opts.seed))
+	for len(runes) > 0 {
+		size := opts.chunkSize
+		if opts.randomChunks > 0 {
+			size = 1 + rnd.IntN(opts.randomChunks)
+		}
+		size = min(size, len(runes))
+		chunks = append(chunks, string(runes[:size]))
+		runes = runes[size:]
+	}
+	return
+}
+
+type output struct {
+	opts   options
+	w      io.Writer
+	enc    *streamtagparser.JSONLEncoder
+	colors bool
+	count  int
+}
+
+func newOutput(opts options, w io.Writer) (*output, error) {
+	o := &output{opts: opts, w: w}
+	switch {
+	case opts.extract != "":
+		if opts.out != "" {
+			if err := os.MkdirAll(opts.out, 0o755); err != nil {
+				return nil, err
+			}
+		}
+	case opts.format == "jsonl":
+		o.enc = streamtagparser.NewJSONLEncoder(w)
+	case opts.format == "pretty":
+	default:
+		return nil, fmt.Errorf("unknown format %q", opts.format)
+	}
+
+	switch opts.color {
+	case "always":
+		o.colors = true
+	case "never":
+	case "auto":
+		o.colors = isTerminal(w) && os.Getenv("NO_COLOR") == ""
+	default:
+		return nil, fmt.Errorf("unknown color mode %q", opts.color)
+	}
+	return o, nil
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+const (
+	colorReset = "\x1b[0m"
+	colorTag   = "\x1b[1;36m"
+	colorBody  = "\x1b[32m"
+)
+
+func (o *output) write(tagsData []*streamtagparser.TagStreamData) error {
+	for _, data := range tagsData {
+		var err error
+		switch {
+		case o.opts.extract != "":
+			err = o.extract(data)
+		case o.enc != nil:
+			err = o.enc.Encode(data)
+		default:
+			err = o.pretty(data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *output) pretty(data *streamtagparser.TagStreamData) error {
+	var s string
+	switch data.Type {
+	case streamtagparser.TagStreamTypeText:
+		s = data.Text
+	case streamtagparser.TagStreamTypeStart:
+		s = o.paint(colorTag, "["+data.TagName+formatAttrs(data.Attrs)+"]")
+	case streamtagparser.TagStreamTypeContent:
+		s = o.paint(colorBody, data.Content)
+	case streamtagparser.TagStreamTypeEnd:
+		s = o.paint(colorTag, "[/"+data.TagName+"]")
+	}
+	_, err := io.WriteString(o.w, s)
+	return err
+}
+
+func (o *output) paint(color, s string) string {
+	if !o.colors || s == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func formatAttrs(attrs []streamtagparser.TagAttr) string {
+	var b strings.Builder
+	for _, attr := range attrs {
+		fmt.Fprintf(&b, " %s=%q", attr.Name, attr.Value)
+	}
+	return b.String()
+}
+
+func (o *output) extract(data *streamtagparser.TagStreamData) error {
+	if data.Type != streamtagparser.TagStreamTypeEnd || data.TagName != o.opts.extract {
+		return nil
+	}
+	o.count++
+	if o.opts.out == "" {
+		_, err := fmt.Fprintln(o.w, data.Content)
+		return err
+	}
+	name := fmt.Sprintf("%s-%d.txt", data.TagName, o.count)
+	return os.WriteFile(filepath.Join(o.opts.out, name), []byte(data.Content), 0o644)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const transcript = `Here <Artifact id="a">one</Artifact> and <Artifact id="b">two</Artifact>.`
+
+func TestRun(t *testing.T) {
+	t.Run("jsonl", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := run([]string{"-tag", "Artifact"}, strings.NewReader(transcript), &stdout, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Count(stdout.String(), "\n"); lines != 9 {
+			t.Fatalf("expected 9 events, got: %d\n%s", lines, stdout.String())
+		}
+	})
+
+	t.Run("rechunked", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"-chunk-size", "1"},
+			{"-random-chunks", "5", "-seed", "7"},
+		} {
+			var stdout bytes.Buffer
+			args = append(args, "-tag", "Artifact", "-format", "pretty", "-color", "never")
+			if err := run(args, strings.NewReader(transcript), &stdout, nil); err != nil {
+				t.Fatal(err)
+			}
+			expected := `Here [Artifact id="a"]one[/Artifact] and [Artifact id="b"]two[/Artifact].`
+			if stdout.String() != expected {
+				t.Fatalf("args: %v, expected: %s, got: %s", args, expected, stdout.String())
+			}
+		}
+
+		args := []string{"-tag", "Artifact", "-chunk-size", "1", "-random-chunks", "5"}
+		if err := run(args, strings.NewReader(transcript), io.Discard, nil); err == nil {
+			t.Fatal("expected an error for conflicting chunk flags")
+		}
+	})
+
+	t.Run("extract", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := run([]string{"-extract", "Artifact"}, strings.NewReader(transcript), &stdout, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stdout.String() != "one\ntwo\n" {
+			t.Fatalf("unexpected output: %q", stdout.String())
+		}
+
+		dir := t.TempDir()
+		err = run(
+			[]string{"-extract", "Artifact", "-out", dir},
+			strings.NewReader(transcript),
+			&stdout,
+			nil,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(filepath.Join(dir, "Artifact-2.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "two" {
+			t.Fatalf("unexpected file content: %q", content)
+		}
+
+		// the extracted tag is parsed along with the others
+		stdout.Reset()
+		err = run(
+			[]string{"-tag", "Think", "-extract", "Artifact"},
+			strings.NewReader(transcript),
+			&stdout,
+			nil,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stdout.String() != "one\ntwo\n" {
+			t.Fatalf("unexpected output: %q", stdout.String())
+		}
+	})
+}
+
+func TestCompleteRunes(t *testing.T) {
+	s := "ab你"
+	for n, expected := range map[int]int{2: 2, 3: 2, 4: 2, 5: 5} {
+		if end := completeRunes([]byte(s[:n])); end != expected {
+			t.Fatalf("prefix %q: expected %d, got: %d", s[:n], expected, end)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package streamtagparser
+
+import "strings"
+
+type codeBlockState int
+
+const (
+	codeBlockNone codeBlockState = iota
+	codeBlockOpening
+	codeBlockContent
+)
+
+// codeBlock parses a Markdown fenced code block of the text, see WithCodeBlocks
+type codeBlock struct {
+	state codeBlockState
+	char  rune // ` or ~
+	fence int  // length of the opening fence
+
+	opening strings.Builder // the opening line, held back until its newline
+	info    strings.Builder
+	attrs   []TagAttr
+	content strings.Builder
+
+	// a line that may be the closing fence is held back until its newline
+	lineStart bool
+	indent    int
+	closing   strings.Builder
+	closeRun  int
+}
+
+func (c *codeBlock) reset() {
+	c.state = codeBlockNone
+	c.char = 0
+	c.fence = 0
+	c.opening.Reset()
+	c.info.Reset()
+	c.attrs = nil
+	c.content.Reset()
+	c.resetLine(false)
+}
+
+func (c *codeBlock) resetLine(lineStart bool) {
+	c.lineStart = lineStart
+	c.indent = 0
+	c.closing.Reset()
+	c.closeRun = 0
+}
+
+// startsCodeBlock reports whether r opens a fence, which needs a backtick or tilde at the start
+// of a line of text after at most three spaces of indentation
+func (p *TagParser) startsCodeBlock(r rune) bool {
+	return p.codeBlockTag != "" && (r == '`' || r == '~') &&
+		p.markdown.lineStart && p.markdown.indent <= 3
+}
+
+func (p *TagParser) parseCodeBlockRune(r rune) (tags []*TagStreamData) {
+	c := &p.codeBlock
+	switch c.state {
+	case codeBlockNone:
+		c.state = codeBlockOpening
+		c.char = r
+		c.fence = 1
+		c.opening.WriteRune(r)
+		return nil
+	case codeBlockOpening:
+		if r == c.char && c.info.Len() == 0 {
+			c.fence++
+			c.opening.WriteRune(r)
+			return nil
+		}
+		// fewer than three fence characters, or a backtick fence with backticks in its info
+		// string, is not a code block
+		if c.fence < 3 || (c.char == '`' && r == '`') {
+			tags = append(tags, p.text(c.opening.String()))
+			c.reset()
+			return append(tags, p.parseRune(r)...)
+		}
+		if r != '\n' {
+			c.info.WriteRune(r)
+			c.opening.WriteRune(r)
+			return nil
+		}
+		if lang, _, _ := strings.Cut(strings.TrimSpace(c.info.String()), " "); lang != "" {
+			c.attrs = []TagAttr{{Name: "lang", Value: lang}}
+		}
+		c.state = codeBlockContent
+		c.resetLine(true)
+		return append(tags, NewStartTagStreamData(p.codeBlockTag, c.attrs))
+	}
+
+	// codeBlockContent
+	switch {
+	case c.lineStart && c.closeRun == 0 && r == ' ' && c.indent < 3:
+		c.indent++
+		c.closing.WriteRune(r)
+		return nil
+	case c.lineStart && r == c.char && c.closing.Len() == c.indent+c.closeRun:
+		c.closeRun++
+		c.closing.WriteRune(r)
+		return nil
+	case c.lineStart && c.closeRun >= c.fence && (r == ' ' || r == '\t'):
+		c.closing.WriteRune(r)
+		return nil
+	case c.lineStart && c.closeRun >= c.fence && r == '\n':
+		tags = append(tags, NewEndTagStreamData(p.codeBlockTag, c.attrs, c.content.String()))
+		c.reset()
+		// the newline after the closing fence stays in the text, like the text after a tag
+		return append(tags, p.text("\n"))
+	}
+
+	content := c.closing.String() + string(r)
+	c.content.WriteString(content)
+	c.resetLine(r == '\n')
+	return append(tags, NewContentTagStreamData(p.codeBlockTag, content))
+}
+
+// codeBlockDone finishes a code block left open at the end of the stream
+func (p *TagParser) codeBlockDone() (tags []*TagStreamData) {
+	c := &p.codeBlock
+	switch c.state {
+	case codeBlockOpening:
+		tags = append(tags, p.text(c.opening.String()))
+	case codeBlockContent:
+		// a closing fence may end the stream without a newline
+		if c.closeRun < c.fence {
+			c.content.WriteString(c.closing.String())
+		}
+		tags = append(tags, NewEndTagStreamData(p.codeBlockTag, c.attrs, c.content.String()))
+	}
+	c.reset()
+	return
+}
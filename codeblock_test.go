@@ -0,0 +1,133 @@
+package streamtagparser
+
+import "testing"
+
+func TestCodeBlocks(t *testing.T) {
+	newParser := func() *TagParser {
+		return NewTagParserWithOptions([]string{"Artifact"}, WithCodeBlocks("CodeBlock"))
+	}
+	goAttrs := []TagAttr{{Name: "lang", Value: "go"}}
+
+	t.Run("normal", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "Here:\n``",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "Here:\n"},
+					},
+				},
+				{
+					input: "`go {title=x}\nfmt.Println(\"<Artifact>\")\n",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "CodeBlock", Attrs: goAttrs},
+						{
+							Type:    TagStreamTypeContent,
+							TagName: "CodeBlock",
+							Content: "fmt.Println(\"<Artifact>\")\n",
+						},
+					},
+				},
+				{
+					input: "`` x\n  ``",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeContent, TagName: "CodeBlock", Content: "`` x\n"},
+					},
+				},
+				{
+					input: "` \nDone",
+					expectedTags: []*TagStreamData{
+						{
+							Type:    TagStreamTypeEnd,
+							TagName: "CodeBlock",
+							Attrs:   goAttrs,
+							Content: "fmt.Println(\"<Artifact>\")\n`` x\n",
+						},
+						{Type: TagStreamTypeText, Text: "\nDone"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("not a fence", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "``x`` a ```b\n~~<Artifact>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "``x`` a ```b\n~~"},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+					},
+				},
+				{
+					input: "</Artifact>\n```a`b\n```",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeEnd, TagName: "Artifact"},
+						{Type: TagStreamTypeText, Text: "\n```a`b\n"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeText, Text: "```"},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("unterminated", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "~~~~\na\n~~~",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "CodeBlock"},
+						{Type: TagStreamTypeContent, TagName: "CodeBlock", Content: "a\n"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "CodeBlock", Content: "a\n~~~"},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("closing fence at the end", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "```\na\n```",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "CodeBlock"},
+						{Type: TagStreamTypeContent, TagName: "CodeBlock", Content: "a\n"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "CodeBlock", Content: "a\n"},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("fence after a tag on the same line", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "<Artifact>x</Artifact>```go\nz\n```",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "x"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "x"},
+						{Type: TagStreamTypeText, Text: "```go\nz\n"},
+					},
+				},
+			},
+			// the last fence may still open a code block
+			doneDatas: []*TagStreamData{{Type: TagStreamTypeText, Text: "```"}},
+		}
+		testParser(t, testData, newParser())
+	})
+}
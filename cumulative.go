@@ -0,0 +1,50 @@
+package streamtagparser
+
+import "strings"
+
+// WithContentOffsets sets the Offset of content events to the length in bytes of the content
+// of the tag before them, so that a consumer can drop a content event it has already applied
+func WithContentOffsets() Option {
+	return func(p *TagParser) {
+		p.contentOffsets = true
+	}
+}
+
+// WithCumulativeContent sets the Accumulated of content events to the content of the tag so
+// far, including the event, for consumers that replace rather than append. It implies
+// WithContentOffsets. Every content event copies the content so far, which is quadratic in
+// the length of the content
+func WithCumulativeContent() Option {
+	return func(p *TagParser) {
+		p.contentOffsets = true
+		p.cumulative = true
+	}
+}
+
+// contentTracker accumulates the content of the open tag for WithContentOffsets and
+// WithCumulativeContent
+type contentTracker struct {
+	length      int
+	accumulated strings.Builder
+}
+
+func (p *TagParser) trackContent(tagsData []*TagStreamData) {
+	if !p.contentOffsets {
+		return
+	}
+	c := &p.contentTracker
+	for _, data := range tagsData {
+		switch data.Type {
+		case TagStreamTypeStart, TagStreamTypeEnd:
+			c.length = 0
+			c.accumulated.Reset()
+		case TagStreamTypeContent:
+			data.Offset = c.length
+			c.length += len(data.Content)
+			if p.cumulative {
+				c.accumulated.WriteString(data.Content)
+				data.Accumulated = c.accumulated.String()
+			}
+		}
+	}
+}
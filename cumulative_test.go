@@ -0,0 +1,39 @@
+package streamtagparser
+
+import "testing"
+
+func TestCumulativeContent(t *testing.T) {
+	parser := NewTagParserWithOptions([]string{"Artifact"}, WithCumulativeContent())
+	var got []*TagStreamData
+	for _, chunk := range []string{"<Artifact>ab", "c</Art", "ifact><Artifact>d"} {
+		for _, data := range parser.Parse(chunk) {
+			if data.Type == TagStreamTypeContent {
+				got = append(got, data)
+			}
+		}
+	}
+
+	expected := []*TagStreamData{
+		{Content: "ab", Offset: 0, Accumulated: "ab"},
+		{Content: "c", Offset: 2, Accumulated: "abc"},
+		{Content: "d", Offset: 0, Accumulated: "d"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d content events, got: %d", len(expected), len(got))
+	}
+	for i, data := range got {
+		if data.Content != expected[i].Content || data.Offset != expected[i].Offset ||
+			data.Accumulated != expected[i].Accumulated {
+			t.Fatalf("expected %+v, got: %+v", expected[i], data)
+		}
+	}
+}
+
+func TestContentOffsets(t *testing.T) {
+	parser := NewTagParserWithOptions([]string{"Artifact"}, WithContentOffsets())
+	parser.Parse("<Artifact>ab")
+	got := parser.Parse("c")
+	if got[0].Offset != 2 || got[0].Accumulated != "" {
+		t.Fatalf("expected offset 2 without accumulated content, got: %+v", got[0])
+	}
+}
@@ -0,0 +1,56 @@
+package streamtagparser
+
+import "unicode/utf8"
+
+// Delimiters are the markers around tags, a start tag is Open + name [+ " " + attrs] + Close
+// and an end tag is EndOpen + name + Close
+type Delimiters struct {
+	Open    string
+	Close   string
+	EndOpen string
+}
+
+var (
+	// AngleDelimiters <Tag attr="1">...</Tag>, the default
+	AngleDelimiters = Delimiters{Open: "<", Close: ">", EndOpen: "</"}
+	// DoubleBracketDelimiters [[Tag attr="1"]]...[[/Tag]]
+	DoubleBracketDelimiters = Delimiters{Open: "[[", Close: "]]", EndOpen: "[[/"}
+	// TemplateDelimiters {% tag attr="1" %}...{% endtag %}
+	TemplateDelimiters = Delimiters{Open: "{% ", Close: " %}", EndOpen: "{% end"}
+	// SpecialTokenDelimiters <|tool_call|>...<|/tool_call|>
+	SpecialTokenDelimiters = Delimiters{Open: "<|", Close: "|>", EndOpen: "<|/"}
+)
+
+// WithDelimiters parses tags marked by d instead of angle brackets, empty fields keep the
+// AngleDelimiters ones
+func WithDelimiters(d Delimiters) Option {
+	return func(p *TagParser) {
+		if d.Open != "" {
+			p.delimiters.Open = d.Open
+		}
+		if d.Close != "" {
+			p.delimiters.Close = d.Close
+		}
+		if d.EndOpen != "" {
+			p.delimiters.EndOpen = d.EndOpen
+		}
+	}
+}
+
+func (d Delimiters) startTag(tagName string) string {
+	return d.Open + tagName + d.Close
+}
+
+// startTagWithAttrs is the start tag up to the separator before its attributes
+func (d Delimiters) startTagWithAttrs(tagName string) string {
+	return d.Open + tagName + " "
+}
+
+func (d Delimiters) endTag(tagName string) string {
+	return d.EndOpen + tagName + d.Close
+}
+
+func (d Delimiters) openRune() rune {
+	r, _ := utf8.DecodeRuneInString(d.Open)
+	return r
+}
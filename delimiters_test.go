@@ -0,0 +1,75 @@
+package streamtagparser
+
+import "testing"
+
+func TestDelimiters(t *testing.T) {
+	tests := []struct {
+		name       string
+		delimiters Delimiters
+		tag        string
+		inputs     []string
+		text       string
+	}{
+		{
+			name:       "double bracket",
+			delimiters: DoubleBracketDelimiters,
+			tag:        "Tag",
+			inputs:     []string{"a [[[Ta", "g attr=1]", "]x]][[/Tag]", "]b"},
+			text:       "a [",
+		},
+		{
+			name:       "template",
+			delimiters: TemplateDelimiters,
+			tag:        "tag",
+			inputs:     []string{"a {% tag", " attr=1 %}x]]{% end", "tag %}b"},
+			text:       "a ",
+		},
+		{
+			name:       "special token",
+			delimiters: SpecialTokenDelimiters,
+			tag:        "tool_call",
+			inputs:     []string{"a <|tool_call attr=1|>", "x]]<|/tool_call|>b"},
+			text:       "a ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewTagParserWithOptions([]string{tt.tag}, WithDelimiters(tt.delimiters))
+			var tagsData []*TagStreamData
+			for _, input := range tt.inputs {
+				tagsData = append(tagsData, parser.Parse(input)...)
+			}
+			tagsData = append(tagsData, parser.ParseDone()...)
+			tagsData = parser.mergeStreams(tagsData)
+
+			attrs := []TagAttr{{Name: "attr", Value: "1"}}
+			expected := []*TagStreamData{
+				{Type: TagStreamTypeText, Text: tt.text},
+				{Type: TagStreamTypeStart, TagName: tt.tag, Attrs: attrs},
+				{Type: TagStreamTypeContent, TagName: tt.tag, Content: "x]]"},
+				{Type: TagStreamTypeEnd, TagName: tt.tag, Attrs: attrs, Content: "x]]"},
+				{Type: TagStreamTypeText, Text: "b"},
+			}
+			if len(tagsData) != len(expected) {
+				t.Fatalf("expected %d events, got: %d", len(expected), len(tagsData))
+			}
+			for i := range expected {
+				tagEqual(t, expected[i], tagsData[i])
+			}
+		})
+	}
+
+	t.Run("partial name followed by attrs", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "<Art id=1>x</Art>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "<Art id=1>x</Art>"},
+					},
+				},
+			},
+		}
+		testParserTest(t, testData, "Artifact")
+	})
+}
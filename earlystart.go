@@ -0,0 +1,21 @@
+package streamtagparser
+
+// WithEarlyStart emits the start event of a start tag with attributes as soon as its name is
+// known, without attributes, and an attrs_complete event with the attributes once the start
+// tag is complete. If the start tag turns out to be text, an end event without content closes
+// the tag again and the start tag follows as text
+func WithEarlyStart() Option {
+	return func(p *TagParser) {
+		p.earlyStart = true
+	}
+}
+
+// startEarly emits the start event of the start tag being parsed, see WithEarlyStart
+func (p *TagParser) startEarly() (tags []*TagStreamData) {
+	if !p.earlyStart {
+		return nil
+	}
+	tags = p.retract()
+	p.earlyStarted = true
+	return append(tags, NewStartTagStreamData(p.currentTagName, nil))
+}
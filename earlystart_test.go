@@ -0,0 +1,82 @@
+package streamtagparser
+
+import "testing"
+
+func TestEarlyStart(t *testing.T) {
+	newParser := func(opts ...Option) *TagParser {
+		return NewTagParserWithOptions([]string{"Artifact"}, append(opts, WithEarlyStart())...)
+	}
+	attrs := []TagAttr{{Name: "id", Value: "1"}}
+
+	t.Run("start before attributes", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `a <Artifact id=`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "a "},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+					},
+				},
+				{
+					input: `"1">b</Artifact>`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeAttrsComplete, TagName: "Artifact", Attrs: attrs},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "b"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Attrs: attrs, Content: "b"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("no attributes", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "<Artifact>b",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "b"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "b"},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact id="1"`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "<Artifact "},
+						{Type: TagStreamTypeRetract, Retract: 10},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "Artifact"},
+				{Type: TagStreamTypeText, Text: `<Artifact id="1"`},
+			},
+		}
+		testParser(t, testData, newParser(WithSpeculativeTags()))
+	})
+
+	t.Run("late reader", func(t *testing.T) {
+		log := NewEventLog(newParser(), 10)
+		log.Parse(`<Artifact id="1">b`)
+		tagsData, _ := log.Snapshot()
+		if len(tagsData) != 2 {
+			t.Fatalf("expected 2 events, got: %d", len(tagsData))
+		}
+		tagEqual(t, &TagStreamData{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+			tagsData[0])
+	})
+}
@@ -0,0 +1,66 @@
+package streamtagparser
+
+import (
+	"html"
+	"strings"
+)
+
+// maxEntityLen bounds how much an unterminated character reference may hold back content
+const maxEntityLen = 32
+
+// WithDecodeAttrEntities decodes named and numeric character references, e.g. &amp; or
+// &#39;, in attribute values
+func WithDecodeAttrEntities() Option {
+	return func(p *TagParser) {
+		p.decodeAttrEntities = true
+	}
+}
+
+// WithDecodeContentEntities decodes named and numeric character references in the content of
+// tags, a reference split across Parse calls is held back until it is complete
+func WithDecodeContentEntities() Option {
+	return func(p *TagParser) {
+		p.decodeContentEntities = true
+	}
+}
+
+// entityDecoder decodes character references of a stream, holding back a reference until the
+// rune after its name shows where it ends
+type entityDecoder struct {
+	pending strings.Builder
+}
+
+func (d *entityDecoder) write(s string) string {
+	var out strings.Builder
+	for _, r := range s {
+		if d.pending.Len() > 0 {
+			if isEntityRune(r) && d.pending.Len() < maxEntityLen {
+				d.pending.WriteRune(r)
+				continue
+			}
+			if r == ';' {
+				d.pending.WriteRune(r)
+				out.WriteString(d.flush())
+				continue
+			}
+			out.WriteString(d.flush())
+		}
+		if r == '&' {
+			d.pending.WriteRune(r)
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// flush decodes whatever is held back
+func (d *entityDecoder) flush() string {
+	s := html.UnescapeString(d.pending.String())
+	d.pending.Reset()
+	return s
+}
+
+func isEntityRune(r rune) bool {
+	return r == '#' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
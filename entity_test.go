@@ -0,0 +1,86 @@
+package streamtagparser
+
+import "testing"
+
+func TestDecodeEntities(t *testing.T) {
+	t.Run("attrs", func(t *testing.T) {
+		attrs := []TagAttr{{Name: "title", Value: `Tom & "Jerry"`}}
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact title="Tom &amp; &quot;Jerry&#34;">a&amp;b</Artifact>`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a&amp;b"},
+						{
+							Type:    TagStreamTypeEnd,
+							TagName: "Artifact",
+							Attrs:   attrs,
+							Content: "a&amp;b",
+						},
+					},
+				},
+			},
+		}
+		parser := NewTagParserWithOptions([]string{"Artifact"}, WithDecodeAttrEntities())
+		testParser(t, testData, parser)
+	})
+
+	t.Run("content", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "<Artifact>1 &l",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "1 "},
+					},
+				},
+				{
+					input: "t; 2 &#x",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "< 2 "},
+					},
+				},
+				{
+					input: "3E;&& &unknown; &amp",
+					expectedTags: []*TagStreamData{
+						{
+							Type:    TagStreamTypeContent,
+							TagName: "Artifact",
+							Content: ">&& &unknown; ",
+						},
+					},
+				},
+				{
+					input: "</Artifact>&amp;",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "&"},
+						{
+							Type:    TagStreamTypeEnd,
+							TagName: "Artifact",
+							Content: "1 < 2 >&& &unknown; &",
+						},
+						{Type: TagStreamTypeText, Text: "&amp;"},
+					},
+				},
+				{
+					input: "<Artifact><![CDATA[&amp;]]>&amp;",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "&amp;&"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "&amp;&"},
+			},
+		}
+		parser := NewTagParserWithOptions(
+			[]string{"Artifact"},
+			WithDecodeContentEntities(),
+			WithStripCDATA(),
+		)
+		testParser(t, testData, parser)
+	})
+}
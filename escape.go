@@ -0,0 +1,169 @@
+package streamtagparser
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Escape neutralises every start or end tag of the parser's tags in s, e.g. from user input
+// or retrieved documents placed in a prompt, by replacing the first rune of its delimiter
+// with a character reference: <Artifact becomes &lt;Artifact. With WithCodeBlocks the first
+// rune of a fence at the start of a line is replaced too, ~~~ becomes &#126;~~. Text that
+// Unescape would turn back into a delimiter or fence is escaped as well, so
+// Unescape(Escape(s)) == s
+func (p *TagParser) Escape(s string) string {
+	patterns := p.escapePatterns()
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		lineStart := atFenceStart(s, i)
+		if q, ok := matchRaw(patterns, s[i:], lineStart); ok {
+			b.WriteString(q.escapedRune)
+			i += len(q.rawRune)
+			continue
+		}
+		if s[i] == '&' && isEscapedPattern(patterns, s[i+1:], lineStart) {
+			b.WriteString("&amp;")
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteString(s[i : i+size])
+		i += size
+	}
+	return b.String()
+}
+
+// Unescape reverts Escape
+func (p *TagParser) Unescape(s string) string {
+	patterns := p.escapePatterns()
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		lineStart := atFenceStart(s, i)
+		if q, ok := matchEscaped(patterns, s[i:], lineStart); ok {
+			b.WriteString(q.rawRune)
+			i += len(q.escapedRune)
+			continue
+		}
+		rest, ok := strings.CutPrefix(s[i:], "&amp;")
+		if ok && isEscapedPattern(patterns, rest, lineStart) {
+			b.WriteString("&")
+			i += len("&amp;")
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteString(s[i : i+size])
+		i += size
+	}
+	return b.String()
+}
+
+// Contains reports whether s contains a start or end tag of the parser's tags, complete or
+// not, or anything else a parser configured like p would emit as a non-text event
+func (p *TagParser) Contains(s string) bool {
+	patterns := p.escapePatterns()
+	for i := range s {
+		if _, ok := matchRaw(patterns, s[i:], atFenceStart(s, i)); ok {
+			return true
+		}
+	}
+	parser := NewTagParserWithOptions(p.needParsed, p.opts...)
+	for _, data := range append(parser.Parse(s), parser.ParseDone()...) {
+		if data.Type != TagStreamTypeText {
+			return true
+		}
+	}
+	return false
+}
+
+// escapePattern is the beginning of a start or end tag, or a fence at the start of a line,
+// its first rune is escaped and the tail that follows stays as is
+type escapePattern struct {
+	rawRune     string
+	escapedRune string
+	tail        string
+	lineStart   bool
+}
+
+func (p *TagParser) escapePatterns() (patterns []escapePattern) {
+	for _, tag := range p.needParsed {
+		for _, open := range []string{p.delimiters.Open, p.delimiters.EndOpen} {
+			r, size := utf8.DecodeRuneInString(open)
+			patterns = append(patterns, escapePattern{
+				rawRune:     open[:size],
+				escapedRune: escapeRune(r),
+				tail:        open[size:] + tag,
+			})
+		}
+	}
+	if p.codeBlockTag != "" {
+		for _, r := range "`~" {
+			patterns = append(patterns, escapePattern{
+				rawRune:     string(r),
+				escapedRune: escapeRune(r),
+				tail:        strings.Repeat(string(r), 2),
+				lineStart:   true,
+			})
+		}
+	}
+	return
+}
+
+func escapeRune(r rune) string {
+	if r == '<' {
+		return "&lt;"
+	}
+	return "&#" + strconv.Itoa(int(r)) + ";"
+}
+
+// matches reports whether s starts with q, its first rune written as first. lineStart tells
+// whether s is at the start of a line, see atFenceStart
+func (q escapePattern) matches(s, first string, lineStart bool) bool {
+	return strings.HasPrefix(s, first+q.tail) && (!q.lineStart || lineStart)
+}
+
+func matchRaw(patterns []escapePattern, s string, lineStart bool) (escapePattern, bool) {
+	for _, q := range patterns {
+		if q.matches(s, q.rawRune, lineStart) {
+			return q, true
+		}
+	}
+	return escapePattern{}, false
+}
+
+func matchEscaped(patterns []escapePattern, s string, lineStart bool) (escapePattern, bool) {
+	for _, q := range patterns {
+		if q.matches(s, q.escapedRune, lineStart) {
+			return q, true
+		}
+	}
+	return escapePattern{}, false
+}
+
+// isEscapedPattern reports whether s, following an "&", continues an escaped pattern with any
+// number of "amp;" inserted after its "&"
+func isEscapedPattern(patterns []escapePattern, s string, lineStart bool) bool {
+	for {
+		var more bool
+		if s, more = strings.CutPrefix(s, "amp;"); !more {
+			break
+		}
+	}
+	for _, q := range patterns {
+		// every escaped rune starts with "&"
+		if q.matches(s, q.escapedRune[1:], lineStart) {
+			return true
+		}
+	}
+	return false
+}
+
+// atFenceStart reports whether s[i:] may start a fence: at the start of a line after at most
+// three spaces, like the code blocks of WithCodeBlocks
+func atFenceStart(s string, i int) bool {
+	j := i
+	for j > 0 && i-j < 3 && s[j-1] == ' ' {
+		j--
+	}
+	return j == 0 || s[j-1] == '\n'
+}
@@ -0,0 +1,94 @@
+package streamtagparser
+
+import "testing"
+
+func TestEscape(t *testing.T) {
+	parser := NewTagParser("Artifact", "Think")
+	tests := []struct {
+		input   string
+		escaped string
+	}{
+		{input: "plain <b>text</b> & more", escaped: "plain <b>text</b> & more"},
+		{
+			input:   `see <Artifact id="1">x</Artifact> and <<Think`,
+			escaped: `see &lt;Artifact id="1">x&lt;/Artifact> and <&lt;Think`,
+		},
+		{input: "&lt;Artifact", escaped: "&amp;lt;Artifact"},
+		{input: "&amp;lt;/Think &lt;Thin", escaped: "&amp;amp;lt;/Think &lt;Thin"},
+	}
+	for _, tt := range tests {
+		escaped := parser.Escape(tt.input)
+		if escaped != tt.escaped {
+			t.Fatalf("input: %q, expected: %q, got: %q", tt.input, tt.escaped, escaped)
+		}
+		if parser.Contains(escaped) {
+			t.Fatalf("escaped %q still contains a tag", escaped)
+		}
+		if unescaped := parser.Unescape(escaped); unescaped != tt.input {
+			t.Fatalf("expected unescaped: %q, got: %q", tt.input, unescaped)
+		}
+	}
+
+	brackets := NewTagParserWithOptions(
+		[]string{"Tag"},
+		WithDelimiters(DoubleBracketDelimiters),
+	)
+	if escaped := brackets.Escape("[[Tag]]x[[/Tag]]"); escaped != "&#91;[Tag]]x&#91;[/Tag]]" {
+		t.Fatalf("unexpected escaped: %q", escaped)
+	}
+
+	codeBlocks := NewTagParserWithOptions([]string{"Artifact"}, WithCodeBlocks("CodeBlock"))
+	for _, tt := range []struct {
+		input   string
+		escaped string
+	}{
+		{input: "```go\n<Artifact>\n```", escaped: "&#96;``go\n&lt;Artifact>\n&#96;``"},
+		{input: "a ``` b\n   ~~~\n    ~~~", escaped: "a ``` b\n   &#126;~~\n    ~~~"},
+		{input: "&#96;``\n``&#96;", escaped: "&amp;#96;``\n``&#96;"},
+	} {
+		escaped := codeBlocks.Escape(tt.input)
+		if escaped != tt.escaped {
+			t.Fatalf("input: %q, expected: %q, got: %q", tt.input, tt.escaped, escaped)
+		}
+		if codeBlocks.Contains(escaped) {
+			t.Fatalf("escaped %q still contains a tag", escaped)
+		}
+		if unescaped := codeBlocks.Unescape(escaped); unescaped != tt.input {
+			t.Fatalf("expected unescaped: %q, got: %q", tt.input, unescaped)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	parser := NewTagParserWithOptions([]string{"Artifact"}, WithCodeBlocks("CodeBlock"))
+	for input, expected := range map[string]bool{
+		"nothing here":         false,
+		"<b>bold</b>":          false,
+		"unfinished <Artifact": true,
+		"stray </Artifact>":    true,
+		"```go\nfmt.Println()": true,
+	} {
+		if parser.Contains(input) != expected {
+			t.Fatalf("input: %q, expected: %v", input, expected)
+		}
+	}
+}
+
+func FuzzEscape(f *testing.F) {
+	f.Add(`see <Artifact id="1">x</Artifact> &amp;lt;/Artifact`)
+	f.Add("```go\n<A>\n  &#96;``\n~~~")
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, parser := range []*TagParser{
+			NewTagParser("Artifact", "A"),
+			NewTagParserWithOptions([]string{"A"}, WithCodeBlocks("CodeBlock")),
+		} {
+			escaped := parser.Escape(input)
+			if parser.Contains(escaped) {
+				t.Fatalf("escaped %q still contains a tag", escaped)
+			}
+			if unescaped := parser.Unescape(escaped); unescaped != input {
+				t.Fatalf("expected unescaped: %q, got: %q", input, unescaped)
+			}
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package streamtagparser
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// LoggedEvent is a TagStreamData with the sequence number assigned by an EventLog
+type LoggedEvent struct {
+	ID   uint64         `json:"id"`
+	Data *TagStreamData `json:"data"`
+}
+
+// EventLog feeds a TagParser and keeps the last capacity events in memory, so that a client
+// which reconnects (e.g. with Last-Event-ID) can replay what it missed.
+// Concurrency safe, Parse and ParseDone are meant to be called by the single producer of the
+// stream while any number of readers call After and Snapshot
+type EventLog struct {
+	mu       sync.Mutex
+	parser   *TagParser
+	capacity int
+
+	events []LoggedEvent
+	lastID uint64
+	open   openTag
+}
+
+// NewEventLog capacity <= 0 means the log is unbounded
+func NewEventLog(parser *TagParser, capacity int) *EventLog {
+	return &EventLog{
+		parser:   parser,
+		capacity: capacity,
+	}
+}
+
+func (l *EventLog) Parse(streamStr string) []LoggedEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.append(l.parser.Parse(streamStr))
+}
+
+func (l *EventLog) ParseDone() []LoggedEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.append(l.parser.ParseDone())
+}
+
+func (l *EventLog) append(tagsData []*TagStreamData) (logged []LoggedEvent) {
+	for _, data := range tagsData {
+		l.lastID++
+		event := LoggedEvent{ID: l.lastID, Data: data}
+		l.events = append(l.events, event)
+		l.open.observe(data)
+		logged = append(logged, event)
+	}
+	if l.capacity > 0 && len(l.events) > l.capacity {
+		l.events = slices.Delete(l.events, 0, len(l.events)-l.capacity)
+	}
+	return
+}
+
+// LastID returns the id of the newest event, 0 if nothing was logged yet
+func (l *EventLog) LastID() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastID
+}
+
+// After returns the events logged after id. ok is false when some of them have already been
+// evicted, in which case the retained events are returned and the caller should fall back
+// to Snapshot
+func (l *EventLog) After(id uint64) (events []LoggedEvent, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if id >= l.lastID {
+		return nil, true
+	}
+	if len(l.events) == 0 {
+		return nil, false
+	}
+	first := l.events[0].ID
+	if id+1 < first {
+		return slices.Clone(l.events), false
+	}
+	return slices.Clone(l.events[id+1-first:]), true
+}
+
+// Snapshot returns the compacted state of the stream: a start event followed by a single
+// content event with everything accumulated so far for the tag that is currently open, and
+// the id of the newest event it covers. Resume with After(lastID) to continue from it
+func (l *EventLog) Snapshot() (tagsData []*TagStreamData, lastID uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.open.catchUp(), l.lastID
+}
+
+// openTag accumulates the tag that is currently open in a stream of events
+type openTag struct {
+	start   *TagStreamData
+	content strings.Builder
+}
+
+func (o *openTag) observe(data *TagStreamData) {
+	switch data.Type {
+	case TagStreamTypeStart:
+		o.start = data
+		o.content.Reset()
+	case TagStreamTypeAttrsComplete:
+		// an early start event had no attributes yet
+		if o.start != nil {
+			start := NewStartTagStreamData(o.start.TagName, data.Attrs)
+			start.ID = o.start.ID
+			o.start = start
+		}
+	case TagStreamTypeContent:
+		if o.start != nil {
+			o.content.WriteString(data.Content)
+		}
+	case TagStreamTypeEnd:
+		o.start = nil
+		o.content.Reset()
+	}
+}
+
+// catchUp synthesises the events a late reader needs to render the open tag
+func (o *openTag) catchUp() (tagsData []*TagStreamData) {
+	if o.start == nil {
+		return nil
+	}
+	tagsData = append(tagsData, NewStartTagStreamData(o.start.TagName, o.start.Attrs))
+	if o.content.Len() > 0 {
+		tagsData = append(tagsData, NewContentTagStreamData(o.start.TagName, o.content.String()))
+	}
+	for _, data := range tagsData {
+		data.ID = o.start.ID
+	}
+	return
+}
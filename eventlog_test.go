@@ -0,0 +1,61 @@
+package streamtagparser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEventLog(t *testing.T) {
+	log := NewEventLog(NewTagParser("Artifact"), 4)
+
+	logged := log.Parse(`hello <Artifact id="1">abc`)
+	if len(logged) != 3 || logged[0].ID != 1 || logged[2].ID != 3 {
+		t.Fatalf("unexpected logged events: %+v", logged)
+	}
+	log.Parse("def")
+
+	events, ok := log.After(1)
+	if !ok || len(events) != 3 {
+		t.Fatalf("expected 3 retained events, got: %d, ok: %v", len(events), ok)
+	}
+	tagEqual(t, &TagStreamData{Type: TagStreamTypeContent, TagName: "Artifact", Content: "def"},
+		events[2].Data)
+
+	snapshot, lastID := log.Snapshot()
+	if lastID != 4 || len(snapshot) != 2 {
+		t.Fatalf("unexpected snapshot: %d events, last id: %d", len(snapshot), lastID)
+	}
+	tagEqual(t, &TagStreamData{
+		Type:    TagStreamTypeStart,
+		TagName: "Artifact",
+		Attrs:   []TagAttr{{Name: "id", Value: "1"}},
+	}, snapshot[0])
+	tagEqual(t, &TagStreamData{
+		Type:    TagStreamTypeContent,
+		TagName: "Artifact",
+		Content: "abcdef",
+	}, snapshot[1])
+
+	log.Parse("</Artifact>bye")
+	if events, ok := log.After(1); ok || len(events) != 4 || events[0].ID != 3 {
+		t.Fatalf("expected evicted events, got: %+v, ok: %v", events, ok)
+	}
+	if events, ok := log.After(6); !ok || len(events) != 0 {
+		t.Fatalf("expected no events after last id, got: %+v, ok: %v", events, ok)
+	}
+	if snapshot, _ := log.Snapshot(); len(snapshot) != 0 {
+		t.Fatalf("expected empty snapshot, got: %d events", len(snapshot))
+	}
+}
+
+func TestLastEventID(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, ok := LastEventID(r); ok {
+		t.Fatal("expected no last event id")
+	}
+	r.Header.Set("Last-Event-ID", "42")
+	if id, ok := LastEventID(r); !ok || id != 42 {
+		t.Fatalf("expected last event id: 42, got: %d", id)
+	}
+}
@@ -0,0 +1,168 @@
+package streamtagparser
+
+import (
+	"sync"
+	"time"
+)
+
+// WithMaxHoldBytes flushes the parser, see TagParser.Flush, as soon as it holds back more than
+// n bytes, which bounds how long a stalled or runaway tag prefix hides text from the user.
+// A start tag, end tag or code fence longer than n bytes is then never recognised
+func WithMaxHoldBytes(n int) Option {
+	return func(p *TagParser) {
+		p.maxHoldBytes = n
+	}
+}
+
+// Flush releases what the parser holds back without ending the stream: a possible start tag
+// or opening fence as text, a possible end tag or character reference as content. A tag
+// holding back what followed a plain end tag is closed there, see WithEndNonce.
+// Whatever it released cannot become a tag anymore
+func (p *TagParser) Flush() []*TagStreamData {
+	return p.finish(p.mergeStreams(p.flush()))
+}
+
+func (p *TagParser) flush() (tags []*TagStreamData) {
+	c := &p.codeBlock
+	switch {
+	case c.state == codeBlockOpening:
+		tags = append(tags, p.text(c.opening.String()))
+		c.reset()
+	case c.state == codeBlockContent:
+		if c.closing.Len() > 0 {
+			content := c.closing.String()
+			c.content.WriteString(content)
+			tags = append(tags, NewContentTagStreamData(p.codeBlockTag, content))
+		}
+		c.resetLine(false)
+	case p.inTagName || p.inAttr:
+		tags = append(tags, p.release(p.tagTotalBuffer.String())...)
+		p.initStatus()
+	case p.hasPlainEnd:
+		// what follows a plain end tag is not committed as content, see WithEndNonce
+		tags = append(tags, p.closeAtPlainEnd()...)
+		return append(tags, p.flush()...)
+	case p.inTagContent:
+		if held := p.tagEndBuffer.String(); held != "" {
+			p.tagEndBuffer.Reset()
+			tags = append(tags, p.content(held))
+		}
+		if tail := p.entities.flush(); tail != "" {
+			tags = append(tags, p.appendContent(tail))
+		}
+	}
+	return
+}
+
+// held returns the number of bytes the parser holds back
+func (p *TagParser) held() int {
+	c := &p.codeBlock
+	switch {
+	case c.state == codeBlockOpening:
+		return c.opening.Len()
+	case c.state == codeBlockContent:
+		return c.closing.Len()
+	case p.inTagName || p.inAttr:
+		return p.tagTotalBuffer.Len()
+	case p.inTagContent:
+		return p.tagEndBuffer.Len() + p.entities.pending.Len() + p.heldContent()
+	}
+	return 0
+}
+
+// Clock schedules the idle flush of an IdleFlusher, tests replace the system clock with a fake
+type Clock interface {
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a flush scheduled by a Clock
+type Timer interface {
+	Stop() bool
+}
+
+type systemClock struct{}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// IdleFlusher wraps a TagParser and flushes it when the stream has been idle for a while with
+// bytes held back, so that a stalled model does not hide text behind an ambiguous tag prefix.
+// Since a flush happens on its own, every event is delivered through the emit callback, in
+// order and never concurrently. emit must not call back into the IdleFlusher.
+// Concurrency safe
+type IdleFlusher struct {
+	// Clock defaults to the system clock, it may be replaced before the first Parse call
+	Clock Clock
+
+	mu     sync.Mutex
+	parser *TagParser
+	idle   time.Duration
+	emit   func(tagsData []*TagStreamData)
+	timer  Timer
+	gen    uint64 // bumped by every Parse call, a timer of an older generation is stale
+	done   bool
+}
+
+func NewIdleFlusher(
+	parser *TagParser,
+	idle time.Duration,
+	emit func(tagsData []*TagStreamData),
+) *IdleFlusher {
+	return &IdleFlusher{
+		Clock:  systemClock{},
+		parser: parser,
+		idle:   idle,
+		emit:   emit,
+	}
+}
+
+func (f *IdleFlusher) Parse(streamStr string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done {
+		return
+	}
+	f.stop()
+	f.send(f.parser.Parse(streamStr))
+	if f.parser.held() > 0 {
+		gen := f.gen
+		f.timer = f.Clock.AfterFunc(f.idle, func() { f.idleFlush(gen) })
+	}
+}
+
+func (f *IdleFlusher) ParseDone() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done {
+		return
+	}
+	f.stop()
+	f.done = true
+	f.send(f.parser.ParseDone())
+}
+
+func (f *IdleFlusher) stop() {
+	f.gen++
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+}
+
+func (f *IdleFlusher) idleFlush(gen uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// the timer may fire while a Parse call that stopped it waits for the lock
+	if f.done || gen != f.gen {
+		return
+	}
+	f.timer = nil
+	f.send(f.parser.Flush())
+}
+
+func (f *IdleFlusher) send(tagsData []*TagStreamData) {
+	if len(tagsData) > 0 {
+		f.emit(tagsData)
+	}
+}
@@ -0,0 +1,203 @@
+package streamtagparser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlush(t *testing.T) {
+	t.Run("start tag prefix", func(t *testing.T) {
+		parser := NewTagParser("Artifact")
+		if got := parser.Parse("hi <Arti"); len(got) != 1 || got[0].Text != "hi " {
+			t.Fatalf("expected the prefix to be held back, got: %v", got)
+		}
+		got := parser.Flush()
+		if len(got) != 1 {
+			t.Fatalf("expected 1 event, got: %d", len(got))
+		}
+		tagEqual(t, &TagStreamData{Type: TagStreamTypeText, Text: "<Arti"}, got[0])
+		// what was flushed cannot become a tag anymore
+		got = parser.Parse("fact>")
+		if len(got) != 1 {
+			t.Fatalf("expected 1 event, got: %d", len(got))
+		}
+		tagEqual(t, &TagStreamData{Type: TagStreamTypeText, Text: "fact>"}, got[0])
+		if got := parser.Flush(); len(got) != 0 {
+			t.Fatalf("expected nothing to flush, got: %d events", len(got))
+		}
+	})
+
+	t.Run("end tag prefix and entity", func(t *testing.T) {
+		parser := NewTagParserWithOptions([]string{"Artifact"}, WithDecodeContentEntities())
+		parser.Parse("<Artifact>a &amp")
+		got := parser.Flush()
+		if len(got) != 1 {
+			t.Fatalf("expected 1 event, got: %d", len(got))
+		}
+		tagEqual(t, &TagStreamData{Type: TagStreamTypeContent, TagName: "Artifact", Content: "&"},
+			got[0])
+
+		parser.Parse("</Art")
+		got = parser.Flush()
+		if len(got) != 1 {
+			t.Fatalf("expected 1 event, got: %d", len(got))
+		}
+		tagEqual(t, &TagStreamData{
+			Type:    TagStreamTypeContent,
+			TagName: "Artifact",
+			Content: "</Art",
+		}, got[0])
+
+		got = parser.Parse("</Artifact>")
+		expected := &TagStreamData{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "a &</Art"}
+		if len(got) != 1 {
+			t.Fatalf("expected 1 event, got: %d", len(got))
+		}
+		tagEqual(t, expected, got[0])
+	})
+
+	t.Run("code fence", func(t *testing.T) {
+		parser := NewTagParserWithOptions(nil, WithCodeBlocks("code"))
+		parser.Parse("```go")
+		got := parser.Flush()
+		if len(got) != 1 {
+			t.Fatalf("expected 1 event, got: %d", len(got))
+		}
+		tagEqual(t, &TagStreamData{Type: TagStreamTypeText, Text: "```go"}, got[0])
+	})
+
+	t.Run("after a plain end tag", func(t *testing.T) {
+		parser := NewTagParserWithOptions([]string{"Artifact"}, WithEndNonce("end"))
+		parser.Parse(`<Artifact end="X7Q">a</Artifact> tail`)
+		got := parser.Flush()
+		// the held text is not committed as content, the tag closes at the plain end tag
+		expected := []*TagStreamData{
+			{
+				Type:    TagStreamTypeEnd,
+				TagName: "Artifact",
+				Attrs:   []TagAttr{{Name: "end", Value: "X7Q"}},
+				Content: "a",
+			},
+			{Type: TagStreamTypeText, Text: " tail"},
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("expected %d events, got: %d", len(expected), len(got))
+		}
+		for i := range got {
+			tagEqual(t, expected[i], got[i])
+		}
+		if got := parser.Parse(" more"); len(got) != 1 || got[0].Text != " more" {
+			t.Fatalf("expected text after the flush, got: %v", got)
+		}
+	})
+}
+
+func TestMaxHoldBytes(t *testing.T) {
+	testData := parserTest{
+		items: []parserTestItem{
+			{
+				input:        "a <Artif",
+				expectedTags: []*TagStreamData{{Type: TagStreamTypeText, Text: "a "}},
+			},
+			{
+				input: `act id="1">b</Artifact> <Artifact>c</Artifact>`,
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeText, Text: `<Artifact id="1">b</Artifact> `},
+					{Type: TagStreamTypeStart, TagName: "Artifact"},
+					{Type: TagStreamTypeContent, TagName: "Artifact", Content: "c"},
+					{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "c"},
+				},
+			},
+		},
+	}
+	testParser(t, testData, NewTagParserWithOptions([]string{"Artifact"}, WithMaxHoldBytes(12)))
+}
+
+func TestMaxHoldBytesAfterPlainEnd(t *testing.T) {
+	attrs := []TagAttr{{Name: "end", Value: "X7Q"}}
+	testData := parserTest{
+		items: []parserTestItem{
+			{
+				input: `<Artifact end="X7Q">a</Artifact> and the rest`,
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+					{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+				},
+			},
+			{
+				input: " of the answer",
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeEnd, TagName: "Artifact", Attrs: attrs, Content: "a"},
+					{Type: TagStreamTypeText, Text: " and the rest of the answer"},
+				},
+			},
+		},
+	}
+	parser := NewTagParserWithOptions(
+		[]string{"Artifact"},
+		WithEndNonce("end"),
+		WithMaxHoldBytes(30),
+	)
+	testParser(t, testData, parser)
+}
+
+type fakeTimer struct {
+	f       func()
+	stopped bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	stopped := t.stopped
+	t.stopped = true
+	return !stopped
+}
+
+type fakeClock struct {
+	timers []*fakeTimer
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func TestIdleFlusher(t *testing.T) {
+	var got []*TagStreamData
+	clock := &fakeClock{}
+	f := NewIdleFlusher(NewTagParser("Artifact"), time.Second, func(tagsData []*TagStreamData) {
+		got = append(got, tagsData...)
+	})
+	f.Clock = clock
+
+	f.Parse("a")
+	if len(clock.timers) != 0 {
+		t.Fatal("expected no timer without held back bytes")
+	}
+	f.Parse(" <Art")
+	f.Parse("if")
+	if len(clock.timers) != 2 || !clock.timers[0].stopped {
+		t.Fatal("expected the first timer to be replaced")
+	}
+	// a stale timer that fired anyway does nothing
+	clock.timers[0].f()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got: %d", len(got))
+	}
+
+	clock.timers[1].f()
+	f.Parse("act>")
+	f.ParseDone()
+	expected := []*TagStreamData{
+		{Type: TagStreamTypeText, Text: "a"},
+		{Type: TagStreamTypeText, Text: " "},
+		{Type: TagStreamTypeText, Text: "<Artif"},
+		{Type: TagStreamTypeText, Text: "act>"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d events, got: %d", len(expected), len(got))
+	}
+	for i := range got {
+		tagEqual(t, expected[i], got[i])
+	}
+}
@@ -0,0 +1,252 @@
+package streamtagparser_test
+
+import (
+	"testing"
+
+	"github.com/liushuangls/streamtagparser"
+	"github.com/liushuangls/streamtagparser/streamtagparsertest"
+)
+
+func FuzzTagParser(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParser("Artifact", "Think")
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		`hello <Artifact id="1">local a=1</Artifact> world`,
+		"<Think>>>!&<</Think>>",
+		"hello <<<Artifact>>>>123456<<</Artifact>>>>",
+		`333 <Artifact "name"="wu">444</Artif>555321</Artifact>123`,
+	)
+}
+
+func FuzzTagParserMarkdownCode(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithIgnoreTagsInMarkdownCode(),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		"use `<Artifact>` or <Artifact>x</Artifact>",
+		"Example:\n  ```xml\n<Artifact>\n```` <Artifact>\n```\n<Artifact>a</Artifact>",
+		"a ` b\n\n<Artifact>",
+		"costs `5\n  <Artifact>a</Artifact> `<Artifact>`\n<b>`",
+	)
+}
+
+func FuzzTagParserCodeBlocks(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithCodeBlocks("CodeBlock"),
+			streamtagparser.WithIgnoreTagsInMarkdownCode(),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		"Here:\n```go {title=x}\nfmt.Println(\"<Artifact>\")\n``` x\n  ``` \nDone",
+		"``x`` a ```b\n~~<Artifact></Artifact>\n```a`b\n```",
+		"~~~~\na\n~~~",
+		"<Artifact>x</Artifact>```go\nz\n```",
+	)
+}
+
+func FuzzTagParserDelimiters(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"tool_call", "Tag"},
+			streamtagparser.WithDelimiters(streamtagparser.SpecialTokenDelimiters),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		"a <|tool_call attr=1|>x]]<|/tool_call|>b",
+		"<|<|Tag|><|/Ta<|/Tag|>",
+	)
+}
+
+func FuzzTagParserTerminators(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithEndNonce("end"),
+			streamtagparser.WithContentLength("length"),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		`<Artifact end="X7Q">a</Artifact>b</Artifact X7Q>c`,
+		`<Artifact end="X7Q">a</Artifact>b</Artifact> <Artifact>c`,
+		`<Artifact length="3">a</Artifact>bc`,
+		`<Artifact end="N">a&lt;/Artifact></Artifact> tail <Artifact id="2">x`,
+	)
+}
+
+func FuzzTagParserCDATA(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithStripCDATA(),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		"<Artifact><![CDATA[<p></Artifact>]]]]></Artifact>",
+		"<Artifact>a<![CDATA[</Artifact>]]>b<![CD",
+	)
+}
+
+func FuzzTagParserEntities(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithDecodeAttrEntities(),
+			streamtagparser.WithDecodeContentEntities(),
+			streamtagparser.WithCDATA(),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		`<Artifact title="Tom &amp; Jerry">1 &lt; 2 &#x3E;&& &unknown; &amp</Artifact>`,
+		"<Artifact>&amp<![CDATA[&amp;]]>&amp;",
+	)
+}
+
+func FuzzTagParserLineStart(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact", "Thinking"},
+			streamtagparser.WithLineStartTags("Artifact"),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		"use x <Artifact> here <Thinking>a</Thinking>",
+		"intro\n  <Artifact id=\"1\">a</Artifact>x <<Artifact>",
+		"\n<Thinking>x</Thinking> <Artifact>",
+	)
+}
+
+func FuzzTagParserMaxHold(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithMaxHoldBytes(12),
+			streamtagparser.WithCodeBlocks("code"),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		`a <Artifact id="1">b</Artifact> <Artifact>c</Artifact>`,
+		"```go\nx\n``` \n```",
+	)
+}
+
+func FuzzTagParserSpeculative(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithSpeculativeTags(),
+			streamtagparser.WithIgnoreTagsInMarkdownCode(),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		`a <Art<Artifact id="1">b</Artifact>`,
+		"`<Artifact>` <Arti",
+	)
+}
+
+func FuzzTagParserPendingHints(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithPendingHints(),
+			streamtagparser.WithSpeculativeTags(),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		`a <Artifact id="1" title='x y' n=2>b</Artifact>`,
+		`<Artifact id="1"`,
+	)
+}
+
+func FuzzTagParserAttrEvents(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithAttrEvents(),
+			streamtagparser.WithAttrDeltas("summary"),
+			streamtagparser.WithDecodeAttrEntities(),
+			streamtagparser.WithMaxAttrBytes(40),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		`<Artifact id="1" summary="Fix &amp; test" n=2>a</Artifact>`,
+		`<Artifact summary=a&lt;b>`,
+		`<Artifact summary="a -> b" n='>'>x`,
+	)
+}
+
+func FuzzTagParserEarlyStart(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithEarlyStart(),
+			streamtagparser.WithSpeculativeTags(),
+			streamtagparser.WithMaxAttrBytes(20),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		`a <Artifact id="1">b</Artifact>`,
+		`<Artifact id="1" title="a long title">b`,
+	)
+}
+
+func FuzzTagParserInstanceIDs(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithInstanceIDs("id"),
+			streamtagparser.WithSequenceNumbers(),
+			streamtagparser.WithCodeBlocks("code"),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		`<Artifact>a</Artifact> <Artifact id="x">b</Artifact>`,
+		"```go\nx\n```\n<Artifact>",
+	)
+}
+
+func FuzzTagParserCumulativeContent(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithCumulativeContent(),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		"<Artifact>ab</Artifact><Artifact>c</Art",
+	)
+}
+
+func FuzzTagParserLineBuffered(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithLineBufferedContent("Artifact"),
+			streamtagparser.WithLineNumbers(),
+			streamtagparser.WithCumulativeContent(),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		"<Artifact>ab\nc\nd</Artifact><Artifact>\n\nx",
+	)
+}
+
+func FuzzTagParserWhitespace(f *testing.F) {
+	newParser := func() streamtagparsertest.Parser {
+		return streamtagparser.NewTagParserWithOptions(
+			[]string{"Artifact"},
+			streamtagparser.WithCollapseWhitespace(),
+			streamtagparser.WithTrimContent(),
+			streamtagparser.WithCodeBlocks("code"),
+		)
+	}
+	streamtagparsertest.Fuzz(f, newParser,
+		"Here:\n\n<Artifact>\r\nx\n</Artifact>\n \nDone",
+		"```go\nx\n```\n\n<Artifact>\n\n",
+	)
+}
@@ -0,0 +1,32 @@
+package streamtagparser
+
+import "slices"
+
+// WithPendingHints emits a pending event as soon as the name of a start tag with attributes
+// is known, and again with the attributes parsed so far each time one of them completes, so
+// that a UI can show a placeholder while a long start tag streams in.
+// The start event follows when the start tag is complete, a pending event without a tag name
+// withdraws the hint if the start tag turns out to be text
+func WithPendingHints() Option {
+	return func(p *TagParser) {
+		p.pendingHints = true
+	}
+}
+
+// hint emits a pending event for the start tag being parsed
+func (p *TagParser) hint() []*TagStreamData {
+	if !p.pendingHints {
+		return nil
+	}
+	return []*TagStreamData{
+		NewPendingTagStreamData(p.currentTagName, slices.Clone(p.scannedAttrs)),
+	}
+}
+
+// withdrawHint withdraws the hint of a start tag with attributes that turned out to be text
+func (p *TagParser) withdrawHint() []*TagStreamData {
+	if !p.pendingHints || !p.inAttr {
+		return nil
+	}
+	return []*TagStreamData{NewPendingTagStreamData("", nil)}
+}
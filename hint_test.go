@@ -0,0 +1,98 @@
+package streamtagparser
+
+import "testing"
+
+func TestPendingHints(t *testing.T) {
+	newParser := func() *TagParser {
+		return NewTagParserWithOptions(
+			[]string{"Artifact"},
+			WithPendingHints(),
+			WithDecodeAttrEntities(),
+		)
+	}
+
+	t.Run("attributes so far", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `a <Artifact id="1" title="Tom &amp;`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "a "},
+						{Type: TagStreamTypePending, TagName: "Artifact"},
+						{
+							Type:    TagStreamTypePending,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "id", Value: "1"}},
+						},
+					},
+				},
+				{
+					input: ` Jerry">b`,
+					expectedTags: []*TagStreamData{
+						{
+							Type:    TagStreamTypePending,
+							TagName: "Artifact",
+							Attrs: []TagAttr{
+								{Name: "id", Value: "1"},
+								{Name: "title", Value: "Tom & Jerry"},
+							},
+						},
+						{
+							Type:    TagStreamTypeStart,
+							TagName: "Artifact",
+							Attrs: []TagAttr{
+								{Name: "id", Value: "1"},
+								{Name: "title", Value: "Tom & Jerry"},
+							},
+						},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "b"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{
+					Type:    TagStreamTypeEnd,
+					TagName: "Artifact",
+					Attrs: []TagAttr{
+						{Name: "id", Value: "1"},
+						{Name: "title", Value: "Tom & Jerry"},
+					},
+					Content: "b",
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("withdrawn", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact id="1"`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypePending, TagName: "Artifact"},
+						{
+							Type:    TagStreamTypePending,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "id", Value: "1"}},
+						},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypePending},
+				{Type: TagStreamTypeText, Text: `<Artifact id="1"`},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("no attributes", func(t *testing.T) {
+		parser := newParser()
+		for _, data := range parser.Parse("<Artifact>a</Artifact>") {
+			if data.Type == TagStreamTypePending {
+				t.Fatal("expected no pending event for a start tag without attributes")
+			}
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package streamtagparser
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrSlowConsumer is reported by Subscription.Err when the hub disconnected a subscriber
+// whose buffer was full
+var ErrSlowConsumer = errors.New("streamtagparser: slow consumer disconnected")
+
+// SlowConsumerPolicy decides what a Hub does when a subscriber's buffer is full
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerDrop drops the event for that subscriber only
+	SlowConsumerDrop SlowConsumerPolicy = iota
+	// SlowConsumerBlock waits until the subscriber has room, which stalls the producer and
+	// every other subscriber
+	SlowConsumerBlock
+	// SlowConsumerDisconnect unsubscribes the subscriber, see ErrSlowConsumer
+	SlowConsumerDisconnect
+)
+
+// Hub owns a single TagParser and fans the parsed events out to many subscribers.
+// Concurrency safe, Parse and ParseDone are meant to be called by the single producer of the
+// stream while Subscribe and Unsubscribe can be called from any goroutine.
+// The events are shared between subscribers and must not be modified
+type Hub struct {
+	// produceMu serialises Parse and ParseDone, it is held while a send blocks so that mu
+	// never is
+	produceMu sync.Mutex
+	parser    *TagParser
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+	open openTag
+	done bool
+}
+
+func NewHub(parser *TagParser) *Hub {
+	return &Hub{
+		parser: parser,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives the events of a Hub, see Hub.Subscribe
+type Subscription struct {
+	hub    *Hub
+	ch     chan *TagStreamData
+	policy SlowConsumerPolicy
+	quit   chan struct{}
+	once   sync.Once
+
+	disconnected atomic.Bool
+	dropped      atomic.Uint64
+
+	mu     sync.Mutex // guards closed and the sends on ch
+	closed bool
+}
+
+// Events is closed when the stream is done, the subscriber is unsubscribed or disconnected
+func (s *Subscription) Events() <-chan *TagStreamData {
+	return s.ch
+}
+
+// Err returns ErrSlowConsumer if the hub disconnected the subscriber
+func (s *Subscription) Err() error {
+	if s.disconnected.Load() {
+		return ErrSlowConsumer
+	}
+	return nil
+}
+
+// Dropped returns the number of events dropped by SlowConsumerDrop
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription) Unsubscribe() {
+	s.hub.Unsubscribe(s)
+}
+
+// close closes the events of s unless they already are
+func (s *Subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+}
+
+func (s *Subscription) closeLocked() {
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// Subscribe registers a subscriber with room for buffer events. A subscriber joining while a
+// tag is open first receives a start event and the content accumulated so far
+func (h *Hub) Subscribe(buffer int, policy SlowConsumerPolicy) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	catchUp := h.open.catchUp()
+	s := &Subscription{
+		hub:    h,
+		ch:     make(chan *TagStreamData, max(buffer, 0)+len(catchUp)),
+		policy: policy,
+		quit:   make(chan struct{}),
+	}
+	for _, data := range catchUp {
+		s.ch <- data
+	}
+	if h.done {
+		s.closeLocked()
+		return s
+	}
+	h.subs[s] = struct{}{}
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscription) {
+	// release a producer blocked on s before taking its lock
+	s.once.Do(func() { close(s.quit) })
+	s.close()
+	h.remove(s)
+}
+
+func (h *Hub) remove(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, s)
+}
+
+// Parse parses streamStr, broadcasts the events and returns them for the producer
+func (h *Hub) Parse(streamStr string) []*TagStreamData {
+	h.produceMu.Lock()
+	defer h.produceMu.Unlock()
+	if h.isDone() {
+		return nil
+	}
+	tagsData := h.parser.Parse(streamStr)
+	h.broadcast(tagsData)
+	return tagsData
+}
+
+// ParseDone finishes the stream and closes every subscription
+func (h *Hub) ParseDone() []*TagStreamData {
+	h.produceMu.Lock()
+	defer h.produceMu.Unlock()
+	if h.isDone() {
+		return nil
+	}
+	tagsData := h.parser.ParseDone()
+	h.broadcast(tagsData)
+
+	h.mu.Lock()
+	h.done = true
+	subs := h.subs
+	h.subs = make(map[*Subscription]struct{})
+	h.mu.Unlock()
+	for s := range subs {
+		s.close()
+	}
+	return tagsData
+}
+
+func (h *Hub) isDone() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.done
+}
+
+func (h *Hub) broadcast(tagsData []*TagStreamData) {
+	for _, data := range tagsData {
+		// a subscriber joining after the snapshot catches up on data instead
+		h.mu.Lock()
+		h.open.observe(data)
+		subs := slices.Collect(maps.Keys(h.subs))
+		h.mu.Unlock()
+		for _, s := range subs {
+			if !h.send(s, data) {
+				h.remove(s)
+			}
+		}
+	}
+}
+
+// send delivers data to s without holding the hub's lock, it reports whether s is still
+// subscribed
+func (h *Hub) send(s *Subscription, data *TagStreamData) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.ch <- data:
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case SlowConsumerBlock:
+		select {
+		case s.ch <- data:
+		case <-s.quit:
+		}
+	case SlowConsumerDisconnect:
+		s.disconnected.Store(true)
+		s.closeLocked()
+		return false
+	default:
+		s.dropped.Add(1)
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package streamtagparser
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func drain(s *Subscription) (tagsData []*TagStreamData) {
+	for data := range s.Events() {
+		tagsData = append(tagsData, data)
+	}
+	return
+}
+
+func TestHub(t *testing.T) {
+	t.Run("late join", func(t *testing.T) {
+		hub := NewHub(NewTagParser("Artifact"))
+		early := hub.Subscribe(10, SlowConsumerBlock)
+
+		hub.Parse(`hi <Artifact id="1">ab`)
+		hub.Parse("c")
+		late := hub.Subscribe(10, SlowConsumerBlock)
+		hub.Parse("d</Artifact>")
+		hub.ParseDone()
+
+		if got := drain(early); len(got) != 6 {
+			t.Fatalf("expected 6 events for early subscriber, got: %d", len(got))
+		}
+		got := drain(late)
+		expected := []*TagStreamData{
+			{
+				Type:    TagStreamTypeStart,
+				TagName: "Artifact",
+				Attrs:   []TagAttr{{Name: "id", Value: "1"}},
+			},
+			{Type: TagStreamTypeContent, TagName: "Artifact", Content: "abc"},
+			{Type: TagStreamTypeContent, TagName: "Artifact", Content: "d"},
+			{
+				Type:    TagStreamTypeEnd,
+				TagName: "Artifact",
+				Attrs:   []TagAttr{{Name: "id", Value: "1"}},
+				Content: "abcd",
+			},
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("expected %d events for late subscriber, got: %d", len(expected), len(got))
+		}
+		for i := range got {
+			tagEqual(t, expected[i], got[i])
+		}
+
+		if s := hub.Subscribe(1, SlowConsumerDrop); len(drain(s)) != 0 {
+			t.Fatal("expected no events after the stream is done")
+		}
+	})
+
+	t.Run("slow consumer policies", func(t *testing.T) {
+		hub := NewHub(NewTagParser("Artifact"))
+		drop := hub.Subscribe(1, SlowConsumerDrop)
+		disconnect := hub.Subscribe(1, SlowConsumerDisconnect)
+
+		hub.Parse("<Artifact>a")
+
+		if drop.Dropped() != 1 {
+			t.Fatalf("expected 1 dropped event, got: %d", drop.Dropped())
+		}
+		if disconnect.Err() != ErrSlowConsumer {
+			t.Fatalf("expected ErrSlowConsumer, got: %v", disconnect.Err())
+		}
+		if got := drain(disconnect); len(got) != 1 {
+			t.Fatalf("expected 1 buffered event, got: %d", len(got))
+		}
+
+		block := hub.Subscribe(0, SlowConsumerBlock)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hub.Parse("b")
+		}()
+		<-block.Events()
+		block.Unsubscribe()
+		wg.Wait()
+		hub.ParseDone()
+	})
+
+	t.Run("blocked send", func(t *testing.T) {
+		hub := NewHub(NewTagParser("Artifact"))
+		block := hub.Subscribe(0, SlowConsumerBlock)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hub.Parse("hello")
+		}()
+		// wait for the producer, which then blocks until block reads
+		for hub.produceMu.TryLock() {
+			hub.produceMu.Unlock()
+			runtime.Gosched()
+		}
+
+		if block.Err() != nil || block.Dropped() != 0 {
+			t.Fatalf("unexpected state: %v, %d", block.Err(), block.Dropped())
+		}
+		other := hub.Subscribe(1, SlowConsumerDrop)
+		if data := <-block.Events(); data.Text != "hello" {
+			t.Fatalf("unexpected event: %+v", data)
+		}
+		wg.Wait()
+		hub.ParseDone()
+		if got := drain(other); len(got) != 0 {
+			t.Fatalf("expected no events for the later subscriber, got: %d", len(got))
+		}
+	})
+
+	t.Run("concurrent subscribe", func(t *testing.T) {
+		hub := NewHub(NewTagParser("Artifact"))
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s := hub.Subscribe(4, SlowConsumerDrop)
+				for range 3 {
+					<-s.Events()
+				}
+				s.Unsubscribe()
+			}()
+		}
+		for i := 0; i < 100; i++ {
+			hub.Parse("<Artifact>x</Artifact>")
+		}
+		hub.ParseDone()
+		wg.Wait()
+	})
+}
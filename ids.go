@@ -0,0 +1,59 @@
+package streamtagparser
+
+import "strconv"
+
+// WithInstanceIDs stamps an ID on the events of every tag occurrence, so that the content of
+// several tags with the same name can be told apart. The ID is the value of the attribute
+// idAttr of the start tag, if idAttr is not empty and the attribute is, and otherwise the
+// number of the occurrence in the stream, starting at 1. Attribute events emitted before the
+// start event, see WithAttrEvents, carry no ID. With WithEarlyStart the start event is emitted
+// before the attributes are known, the ID is then always the number of the occurrence
+func WithInstanceIDs(idAttr string) Option {
+	return func(p *TagParser) {
+		p.instanceIDs = true
+		p.idAttr = idAttr
+	}
+}
+
+// WithSequenceNumbers stamps a Seq on every event, counting from 1 up across the Parse,
+// Flush and ParseDone calls of a stream
+func WithSequenceNumbers() Option {
+	return func(p *TagParser) {
+		p.sequence = true
+	}
+}
+
+func (p *TagParser) stamp(tagsData []*TagStreamData) {
+	for _, data := range tagsData {
+		if p.sequence {
+			p.seq++
+			data.Seq = p.seq
+		}
+		if !p.instanceIDs {
+			continue
+		}
+		switch data.Type {
+		case TagStreamTypeStart:
+			p.instances++
+			p.instanceID = p.newInstanceID(data.Attrs)
+			data.ID = p.instanceID
+		case TagStreamTypeContent, TagStreamTypeAttr, TagStreamTypeAttrDelta,
+			TagStreamTypeAttrsComplete:
+			data.ID = p.instanceID
+		case TagStreamTypeEnd:
+			data.ID = p.instanceID
+			p.instanceID = ""
+		}
+	}
+}
+
+func (p *TagParser) newInstanceID(attrs []TagAttr) string {
+	if p.idAttr != "" {
+		for _, attr := range attrs {
+			if attr.Name == p.idAttr && attr.Value != "" {
+				return attr.Value
+			}
+		}
+	}
+	return strconv.Itoa(p.instances)
+}
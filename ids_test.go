@@ -0,0 +1,83 @@
+package streamtagparser
+
+import "testing"
+
+func TestInstanceIDs(t *testing.T) {
+	parser := NewTagParserWithOptions(
+		[]string{"Artifact"},
+		WithInstanceIDs("id"),
+		WithSequenceNumbers(),
+	)
+	var got []*TagStreamData
+	chunks := []string{"<Artifact>a", `</Artifact> <Artifact id="x">b</Art`, "ifact>"}
+	for _, chunk := range chunks {
+		got = append(got, parser.Parse(chunk)...)
+	}
+	got = append(got, parser.ParseDone()...)
+
+	expected := []struct {
+		typ TagStreamType
+		id  string
+	}{
+		{TagStreamTypeStart, "1"},
+		{TagStreamTypeContent, "1"},
+		{TagStreamTypeEnd, "1"},
+		{TagStreamTypeText, ""},
+		{TagStreamTypeStart, "x"},
+		{TagStreamTypeContent, "x"},
+		{TagStreamTypeEnd, "x"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d events, got: %d", len(expected), len(got))
+	}
+	for i, data := range got {
+		if data.Type != expected[i].typ || data.ID != expected[i].id {
+			t.Fatalf("event %d: expected %s %q, got: %s %q",
+				i, expected[i].typ, expected[i].id, data.Type, data.ID)
+		}
+		if data.Seq != uint64(i+1) {
+			t.Fatalf("event %d: expected seq %d, got: %d", i, i+1, data.Seq)
+		}
+	}
+
+	// a new stream starts counting again
+	got = parser.Parse("<Artifact>")
+	if got[0].ID != "1" || got[0].Seq != 1 {
+		t.Fatalf("expected id 1 and seq 1, got: %q %d", got[0].ID, got[0].Seq)
+	}
+}
+
+func TestInstanceIDsEarlyStart(t *testing.T) {
+	parser := NewTagParserWithOptions(
+		[]string{"Artifact"},
+		WithInstanceIDs("id"),
+		WithEarlyStart(),
+	)
+	got := append(parser.Parse(`<Artifact id="z">a</Artifact>`), parser.ParseDone()...)
+	expected := []TagStreamType{
+		TagStreamTypeStart,
+		TagStreamTypeAttrsComplete,
+		TagStreamTypeContent,
+		TagStreamTypeEnd,
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d events, got: %d", len(expected), len(got))
+	}
+	for i, data := range got {
+		// the start event goes out before the id attribute is known
+		if data.Type != expected[i] || data.ID != "1" {
+			t.Fatalf("event %d: expected %s \"1\", got: %s %q", i, expected[i], data.Type, data.ID)
+		}
+	}
+}
+
+func TestInstanceIDsCatchUp(t *testing.T) {
+	log := NewEventLog(NewTagParserWithOptions([]string{"Artifact"}, WithInstanceIDs("")), 10)
+	log.Parse("<Artifact>a</Artifact><Artifact>b")
+	tagsData, _ := log.Snapshot()
+	for _, data := range tagsData {
+		if data.ID != "2" {
+			t.Fatalf("expected id 2, got: %q", data.ID)
+		}
+	}
+}
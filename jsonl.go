@@ -0,0 +1,40 @@
+package streamtagparser
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONLEncoder writes TagStreamData as JSON Lines, one event per line
+type JSONLEncoder struct {
+	enc *json.Encoder
+}
+
+func NewJSONLEncoder(w io.Writer) *JSONLEncoder {
+	enc := json.NewEncoder(w)
+	// tags and their content are full of <, > and &, keep them readable
+	enc.SetEscapeHTML(false)
+	return &JSONLEncoder{enc: enc}
+}
+
+func (e *JSONLEncoder) Encode(data *TagStreamData) error {
+	return e.enc.Encode(data)
+}
+
+// JSONLDecoder reads TagStreamData written by JSONLEncoder
+type JSONLDecoder struct {
+	dec *json.Decoder
+}
+
+func NewJSONLDecoder(r io.Reader) *JSONLDecoder {
+	return &JSONLDecoder{dec: json.NewDecoder(r)}
+}
+
+// Decode returns io.EOF when there are no more events
+func (d *JSONLDecoder) Decode() (*TagStreamData, error) {
+	var data TagStreamData
+	if err := d.dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
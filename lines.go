@@ -0,0 +1,80 @@
+package streamtagparser
+
+import "strings"
+
+// WithLineBufferedContent emits the content of the tags named tagNames, which may include the
+// tag of WithCodeBlocks, one complete line per content event, newline included. The last line
+// is emitted before the end event even if it has no newline
+func WithLineBufferedContent(tagNames ...string) Option {
+	return func(p *TagParser) {
+		if p.lineTags == nil {
+			p.lineTags = make(map[string]bool)
+		}
+		for _, name := range tagNames {
+			p.lineTags[name] = true
+		}
+	}
+}
+
+// WithLineNumbers sets the Line of the content events of WithLineBufferedContent, counting
+// the lines of every tag from 1
+func WithLineNumbers() Option {
+	return func(p *TagParser) {
+		p.lineNumbers = true
+	}
+}
+
+// lineBuffer holds the incomplete last line of the open tag for WithLineBufferedContent
+type lineBuffer struct {
+	partial strings.Builder
+	line    int
+}
+
+func (p *TagParser) bufferLines(tagsData []*TagStreamData) []*TagStreamData {
+	if len(p.lineTags) == 0 {
+		return tagsData
+	}
+	b := &p.lineBuffer
+	list := make([]*TagStreamData, 0, len(tagsData))
+	for _, data := range tagsData {
+		if !p.lineTags[data.TagName] {
+			list = append(list, data)
+			continue
+		}
+		switch data.Type {
+		case TagStreamTypeStart:
+			b.partial.Reset()
+			b.line = 0
+		case TagStreamTypeContent:
+			b.partial.WriteString(data.Content)
+			content := b.partial.String()
+			for {
+				i := strings.IndexByte(content, '\n')
+				if i == -1 {
+					break
+				}
+				list = append(list, p.newLine(data.TagName, content[:i+1]))
+				content = content[i+1:]
+			}
+			b.partial.Reset()
+			b.partial.WriteString(content)
+			continue
+		case TagStreamTypeEnd:
+			if b.partial.Len() > 0 {
+				list = append(list, p.newLine(data.TagName, b.partial.String()))
+				b.partial.Reset()
+			}
+		}
+		list = append(list, data)
+	}
+	return list
+}
+
+func (p *TagParser) newLine(tagName, line string) *TagStreamData {
+	data := NewContentTagStreamData(tagName, line)
+	p.lineBuffer.line++
+	if p.lineNumbers {
+		data.Line = p.lineBuffer.line
+	}
+	return data
+}
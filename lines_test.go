@@ -0,0 +1,73 @@
+package streamtagparser
+
+import "testing"
+
+func TestLineBufferedContent(t *testing.T) {
+	t.Run("complete lines", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "<Artifact>a",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+					},
+				},
+				{
+					input: "b\nc\nd",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "ab\n"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "c\n"},
+					},
+				},
+				{
+					input: "</Artifact><Thinking>x",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "d"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "ab\nc\nd"},
+						{Type: TagStreamTypeStart, TagName: "Thinking"},
+						{Type: TagStreamTypeContent, TagName: "Thinking", Content: "x"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "Thinking", Content: "x"},
+			},
+		}
+		parser := NewTagParserWithOptions(
+			[]string{"Artifact", "Thinking"},
+			WithLineBufferedContent("Artifact"),
+		)
+		testParser(t, testData, parser)
+	})
+
+	t.Run("line numbers", func(t *testing.T) {
+		parser := NewTagParserWithOptions(
+			nil,
+			WithCodeBlocks("code"),
+			WithLineBufferedContent("code"),
+			WithLineNumbers(),
+		)
+		var lines []int
+		for _, chunk := range []string{"```go\na\n", "b\nc", "\n```\n```\nd"} {
+			for _, data := range parser.Parse(chunk) {
+				if data.Type == TagStreamTypeContent {
+					lines = append(lines, data.Line)
+				}
+			}
+		}
+		for _, data := range parser.ParseDone() {
+			if data.Type == TagStreamTypeContent {
+				lines = append(lines, data.Line)
+			}
+		}
+		expected := []int{1, 2, 3, 1}
+		if len(lines) != len(expected) {
+			t.Fatalf("expected lines: %v, got: %v", expected, lines)
+		}
+		for i := range lines {
+			if lines[i] != expected[i] {
+				t.Fatalf("expected lines: %v, got: %v", expected, lines)
+			}
+		}
+	})
+}
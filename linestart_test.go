@@ -0,0 +1,98 @@
+package streamtagparser
+
+import "testing"
+
+func TestLineStartTags(t *testing.T) {
+	newParser := func() *TagParser {
+		return NewTagParserWithOptions(
+			[]string{"Artifact", "Thinking"},
+			WithLineStartTags("Artifact"),
+		)
+	}
+
+	t.Run("inline mention stays text", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "use x <Artifact> here <Thinking>a</Thinking>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "use x <Artifact> here "},
+						{Type: TagStreamTypeStart, TagName: "Thinking"},
+						{Type: TagStreamTypeContent, TagName: "Thinking", Content: "a"},
+						{Type: TagStreamTypeEnd, TagName: "Thinking", Content: "a"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("indented line start across chunks", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "intro\n ",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "intro\n "},
+					},
+				},
+				{
+					input:        " <Arti",
+					expectedTags: []*TagStreamData{{Type: TagStreamTypeText, Text: " "}},
+				},
+				{
+					input: `fact id="1">a</Artifact>`,
+					expectedTags: []*TagStreamData{
+						{
+							Type:    TagStreamTypeStart,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "id", Value: "1"}},
+						},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+						{
+							Type:    TagStreamTypeEnd,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "id", Value: "1"}},
+							Content: "a",
+						},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("start of stream", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "<Artifact>a</Artifact>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "a"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("after another tag on the same line", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "\n<Thinking>x</Thinking> <Artifact>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "\n"},
+						{Type: TagStreamTypeStart, TagName: "Thinking"},
+						{Type: TagStreamTypeContent, TagName: "Thinking", Content: "x"},
+						{Type: TagStreamTypeEnd, TagName: "Thinking", Content: "x"},
+						{Type: TagStreamTypeText, Text: " <Artifact>"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+}
@@ -0,0 +1,109 @@
+package streamtagparser
+
+// markdownState tracks Markdown code spans and fenced code blocks in the text outside tags
+type markdownState struct {
+	lineStart bool // only indentation since the last newline
+	indent    int
+
+	// pending run of backticks or tildes, its length is only known when it ends
+	run            int
+	runChar        rune
+	runAtLineStart bool
+
+	fence        int // length of the open fence, 0 if none
+	fenceChar    rune
+	fenceInfo    bool // still on the line that opened the fence
+	fenceClosing bool // a closing run was seen, confirmed by the end of the line
+	inline       int  // length of the backticks that opened the code span, 0 if none
+}
+
+func newMarkdownState() markdownState {
+	return markdownState{lineStart: true}
+}
+
+func (m *markdownState) write(s string) {
+	for _, r := range s {
+		m.writeRune(r)
+	}
+}
+
+func (m *markdownState) writeRune(r rune) {
+	if m.run > 0 && r == m.runChar {
+		m.run++
+		return
+	}
+	m.endRun()
+
+	if r == '`' || r == '~' {
+		m.run = 1
+		m.runChar = r
+		m.runAtLineStart = m.lineStart && m.indent <= 3
+		m.lineStart = false
+		m.fenceClosing = false
+		return
+	}
+
+	switch r {
+	case '\n':
+		if m.fenceClosing {
+			m.fence = 0
+			m.fenceClosing = false
+		}
+		// a blank line ends the paragraph, and with it an unclosed code span
+		if m.lineStart {
+			m.inline = 0
+		}
+		m.fenceInfo = false
+		m.lineStart = true
+		m.indent = 0
+	case ' ':
+		m.indent++
+	case '\t':
+		m.indent += 4
+	default:
+		m.fenceClosing = false
+		m.lineStart = false
+	}
+}
+
+func (m *markdownState) endRun() {
+	if m.run == 0 {
+		return
+	}
+	n, c, atLineStart := m.run, m.runChar, m.runAtLineStart
+	m.run = 0
+
+	switch {
+	case m.fence > 0:
+		if atLineStart && !m.fenceInfo && c == m.fenceChar && n >= m.fence {
+			m.fenceClosing = true
+		}
+	case m.inline > 0:
+		if c == '`' && n == m.inline {
+			m.inline = 0
+		}
+	case atLineStart && n >= 3:
+		m.fence = n
+		m.fenceChar = c
+		m.fenceInfo = true
+	case c == '`':
+		m.inline = n
+	}
+}
+
+// skipTag accounts for a tag consumed from the text, which leaves the line started like any
+// other rune would. Tags are only consumed outside code, or after a stray backtick that the tag
+// closes, see TagParser.inMarkdownCode
+func (m *markdownState) skipTag() {
+	m.endRun()
+	m.fenceClosing = false
+	m.lineStart = false
+	m.inline = 0
+}
+
+// inCode reports whether the next rune, which is not a backtick or tilde, is inside a code
+// span or fenced code block
+func (m *markdownState) inCode() bool {
+	m.endRun()
+	return m.fence > 0 || m.inline > 0
+}
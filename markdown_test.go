@@ -0,0 +1,134 @@
+package streamtagparser
+
+import "testing"
+
+func TestIgnoreTagsInMarkdownCode(t *testing.T) {
+	newParser := func() *TagParser {
+		return NewTagParserWithOptions([]string{"Artifact"}, WithIgnoreTagsInMarkdownCode())
+	}
+
+	t.Run("inline code", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "use `<Artifact>` or ``",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "use `<Artifact>` or ``"},
+					},
+				},
+				{
+					input: "<Artifact>`` <Artifact>x</Artifact>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "<Artifact>`` "},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "x"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "x"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("fenced code", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "Example:\n  ``",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "Example:\n  ``"},
+					},
+				},
+				{
+					input: "`xml\n<Artifact>\n```` <Artifact>\n~~~\n",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "`xml\n<Artifact>\n```` <Artifact>\n~~~\n"},
+					},
+				},
+				{
+					input: "```\n<Artifact>a</Artifact>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "```\n"},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "a"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("unclosed span ends with the paragraph", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "a ` b\n\n<Artifact>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "a ` b\n\n"},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{{Type: TagStreamTypeEnd, TagName: "Artifact"}},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("stray backtick before a tag at line start", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "costs `5\n",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "costs `5\n"},
+					},
+				},
+				{
+					input: "  <Artifact>a</Artifact> `b`",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "  "},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "a"},
+						{Type: TagStreamTypeText, Text: " `b`"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("multi-line span with a line starting with <", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "`a\n<b>` <Artifact>`x`</Artifact>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "`a\n<b>` "},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "`x`"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "`x`"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "`<Artifact>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "`"},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{{Type: TagStreamTypeEnd, TagName: "Artifact"}},
+		}
+		testParserTest(t, testData, "Artifact")
+	})
+}
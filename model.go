@@ -7,6 +7,20 @@ const (
 	TagStreamTypeStart   TagStreamType = "start"
 	TagStreamTypeContent TagStreamType = "content"
 	TagStreamTypeEnd     TagStreamType = "end"
+	// TagStreamTypeRetract takes back the last Retract characters of the text, see
+	// WithSpeculativeTags
+	TagStreamTypeRetract TagStreamType = "retract"
+	// TagStreamTypePending announces a start tag that is still streaming in, see
+	// WithPendingHints
+	TagStreamTypePending TagStreamType = "pending"
+	// TagStreamTypeAttr carries an attribute of a start tag that is still streaming in, see
+	// WithAttrEvents
+	TagStreamTypeAttr TagStreamType = "attr"
+	// TagStreamTypeAttrDelta carries the next piece of an attribute value, see WithAttrDeltas
+	TagStreamTypeAttrDelta TagStreamType = "attr_delta"
+	// TagStreamTypeAttrsComplete carries the attributes of a tag whose start event was emitted
+	// before them, see WithEarlyStart
+	TagStreamTypeAttrsComplete TagStreamType = "attrs_complete"
 )
 
 type TagAttr struct {
@@ -22,6 +36,18 @@ type TagStreamData struct {
 	TagName string    `json:"tag_name,omitempty"`
 	Attrs   []TagAttr `json:"attrs,omitempty"`
 	Content string    `json:"content,omitempty"` // content is the content of the tag
+
+	Retract int `json:"retract,omitempty"` // number of runes of text to take back
+
+	// Offset is the length in bytes of the content of the tag before the content event and
+	// Accumulated the content so far, see WithContentOffsets and WithCumulativeContent
+	Offset      int    `json:"offset,omitempty"`
+	Accumulated string `json:"accumulated,omitempty"`
+
+	Line int `json:"line,omitempty"` // line number of the content, see WithLineNumbers
+
+	ID  string `json:"id,omitempty"`  // instance of the tag, see WithInstanceIDs
+	Seq uint64 `json:"seq,omitempty"` // position in the stream, see WithSequenceNumbers
 }
 
 func NewTextTagStreamData(text string) *TagStreamData {
@@ -55,3 +81,42 @@ func NewEndTagStreamData(tagName string, attrs []TagAttr, content string) *TagSt
 		Content: content,
 	}
 }
+
+func NewRetractTagStreamData(n int) *TagStreamData {
+	return &TagStreamData{
+		Type:    TagStreamTypeRetract,
+		Retract: n,
+	}
+}
+
+func NewPendingTagStreamData(tagName string, attrs []TagAttr) *TagStreamData {
+	return &TagStreamData{
+		Type:    TagStreamTypePending,
+		TagName: tagName,
+		Attrs:   attrs,
+	}
+}
+
+func NewAttrTagStreamData(tagName string, attr TagAttr) *TagStreamData {
+	return &TagStreamData{
+		Type:    TagStreamTypeAttr,
+		TagName: tagName,
+		Attrs:   []TagAttr{attr},
+	}
+}
+
+func NewAttrDeltaTagStreamData(tagName string, attr TagAttr) *TagStreamData {
+	return &TagStreamData{
+		Type:    TagStreamTypeAttrDelta,
+		TagName: tagName,
+		Attrs:   []TagAttr{attr},
+	}
+}
+
+func NewAttrsCompleteTagStreamData(tagName string, attrs []TagAttr) *TagStreamData {
+	return &TagStreamData{
+		Type:    TagStreamTypeAttrsComplete,
+		TagName: tagName,
+		Attrs:   attrs,
+	}
+}
@@ -0,0 +1,38 @@
+package streamtagparser
+
+// Option configures optional behaviour of a TagParser, see NewTagParserWithOptions
+type Option func(p *TagParser)
+
+// WithIgnoreTagsInMarkdownCode treats tags inside Markdown code spans (`...`) and fenced code
+// blocks (``` or ~~~) of the text as plain text, e.g. when the model explains the tag format
+// in an example. A code span ends at a blank line if it was never closed, and a tag at the
+// start of a line, after optional indentation, ends it too, so that a stray backtick such as
+// "costs `5" does not swallow the tags of the following lines
+func WithIgnoreTagsInMarkdownCode() Option {
+	return func(p *TagParser) {
+		p.ignoreInMarkdownCode = true
+	}
+}
+
+// WithCodeBlocks emits the Markdown fenced code blocks of the text as start, content and end
+// events named tagName, the start event carries a lang attribute taken from the info string.
+// Code blocks are only recognised outside tags
+func WithCodeBlocks(tagName string) Option {
+	return func(p *TagParser) {
+		p.codeBlockTag = tagName
+	}
+}
+
+// WithLineStartTags only recognises tags as start tags at the start of a line of the text,
+// after optional indentation, which avoids false positives like "x <Artifact" in prose.
+// Other tags are recognised anywhere
+func WithLineStartTags(tags ...string) Option {
+	return func(p *TagParser) {
+		if p.lineStartTags == nil {
+			p.lineStartTags = make(map[string]bool)
+		}
+		for _, tag := range tags {
+			p.lineStartTags[tag] = true
+		}
+	}
+}
@@ -1,10 +1,9 @@
 package streamtagparser
 
 import (
-	"cmp"
-	"fmt"
-	"slices"
+	"html"
 	"strings"
+	"unicode/utf8"
 )
 
 // TagParser Non-concurrency safe, a TagParser can only be used for one stream
@@ -22,12 +21,98 @@ type TagParser struct {
 	inTagContent bool
 
 	currentTagName string
+	tagAtLineStart bool
+	currentAttrs   []TagAttr
+	currentEndTag  string
+	currentMarkers []string
+
+	opts []Option
+
+	ignoreInMarkdownCode bool
+	markdown             markdownState
+
+	codeBlockTag string
+	codeBlock    codeBlock
+
+	delimiters    Delimiters
+	startTags     []string
+	attrStartTags []string
+
+	endNonceAttr      string
+	contentLengthAttr string
+	guarded           bool // the current tag declared a nonce or a content length
+	contentRemaining  int
+	plainEndAt        int             // length of the content before the last plain end tag
+	hasPlainEnd       bool            // content after plainEndAt is held, see guardedMarker
+	heldRaw           strings.Builder // the raw text after the last plain end tag
+
+	cdata      bool
+	stripCDATA bool
+	inCDATA    bool
+
+	lineStartTags map[string]bool
+	maxHoldBytes  int
+	speculative   bool
+	earlyStart    bool
+	earlyStarted  bool // the start event of the start tag being parsed was emitted
+
+	pendingHints bool
+
+	// attributes scanned as they stream in, see scanAttrRune
+	attrEvents    bool
+	deltaAttrs    map[string]bool
+	maxAttrBytes  int
+	attrScan      attrScanner
+	scannedAttrs  []TagAttr
+	deltaRaw      int // bytes of the streamed value fed to deltaEntities
+	deltaSent     int // bytes of the streamed value emitted
+	deltaEntities entityDecoder
+
+	instanceIDs bool
+	idAttr      string
+	instances   int
+	instanceID  string // of the open tag
+	sequence    bool
+	seq         uint64
+
+	contentOffsets bool
+	cumulative     bool
+	contentTracker contentTracker
+
+	lineTags    map[string]bool
+	lineNumbers bool
+	lineBuffer  lineBuffer
+
+	collapseWhitespace bool
+	trimContent        bool
+	whitespace         whitespaceState
+
+	decodeAttrEntities    bool
+	decodeContentEntities bool
+	entities              entityDecoder
 }
 
 func NewTagParser(needParsed ...string) *TagParser {
-	return &TagParser{
+	return NewTagParserWithOptions(needParsed)
+}
+
+func NewTagParserWithOptions(needParsed []string, opts ...Option) *TagParser {
+	p := &TagParser{
 		needParsed: needParsed,
+		opts:       opts,
+		markdown:   newMarkdownState(),
+		delimiters: AngleDelimiters,
+		// 防止ai输出错误
+		maxAttrBytes: 500,
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	for _, tag := range needParsed {
+		p.startTags = append(p.startTags, p.delimiters.startTag(tag))
+		p.attrStartTags = append(p.attrStartTags, p.delimiters.startTagWithAttrs(tag))
+	}
+	return p
 }
 
 func (p *TagParser) initStatus() {
@@ -37,6 +122,22 @@ func (p *TagParser) initStatus() {
 	p.inTagContent = false
 
 	p.currentTagName = ""
+	p.tagAtLineStart = false
+	p.earlyStarted = false
+	p.currentAttrs = nil
+	p.scannedAttrs = nil
+	p.attrScan.reset()
+	p.resetAttrDelta()
+	p.currentEndTag = ""
+	p.currentMarkers = nil
+	p.inCDATA = false
+	p.entities.pending.Reset()
+
+	p.guarded = false
+	p.contentRemaining = 0
+	p.plainEndAt = 0
+	p.hasPlainEnd = false
+	p.heldRaw.Reset()
 
 	p.tagTotalBuffer.Reset()
 	p.tagAttrBuffer.Reset()
@@ -44,27 +145,31 @@ func (p *TagParser) initStatus() {
 	p.tagEndBuffer.Reset()
 }
 
-func (p *TagParser) Parse(streamStr string) (tagsData []*TagStreamData) {
+func (p *TagParser) Parse(streamStr string) []*TagStreamData {
+	return p.finish(p.parse(streamStr))
+}
+
+func (p *TagParser) ParseDone() []*TagStreamData {
+	tagsData := p.finish(p.parseDone())
+	p.resetStream()
+	return tagsData
+}
+
+func (p *TagParser) parse(streamStr string) (tagsData []*TagStreamData) {
 	if streamStr == "" {
 		return nil
 	}
 
 	tagStr := streamStr
 
-	if !p.inTag {
-		i := strings.Index(streamStr, "<")
+	if !p.inTag && p.codeBlock.state == codeBlockNone {
+		i := strings.IndexAny(streamStr, p.textStops())
 		if i == -1 {
-			tagsData = append(tagsData, &TagStreamData{
-				Type: TagStreamTypeText,
-				Text: streamStr,
-			})
+			tagsData = append(tagsData, p.text(streamStr))
 			return
 		} else {
 			if rawText := streamStr[:i]; rawText != "" {
-				tagsData = append(tagsData, &TagStreamData{
-					Type: TagStreamTypeText,
-					Text: rawText,
-				})
+				tagsData = append(tagsData, p.text(rawText))
 			}
 			tagStr = streamStr[i:]
 		}
@@ -72,26 +177,68 @@ func (p *TagParser) Parse(streamStr string) (tagsData []*TagStreamData) {
 
 	for _, r := range tagStr {
 		tagsData = append(tagsData, p.parseRune(r)...)
+		if p.maxHoldBytes > 0 && p.held() > p.maxHoldBytes {
+			tagsData = append(tagsData, p.flush()...)
+		}
 	}
 
 	return p.mergeStreams(tagsData)
 }
 
-func (p *TagParser) ParseDone() (tagsData []*TagStreamData) {
+func (p *TagParser) parseDone() (tagsData []*TagStreamData) {
+	tagsData = append(tagsData, p.codeBlockDone()...)
 	if (p.inTagName || p.inAttr) && p.tagTotalBuffer.Len() > 0 {
-		tagsData = append(tagsData, NewTextTagStreamData(p.tagTotalBuffer.String()))
+		tagsData = append(tagsData, p.release(p.tagTotalBuffer.String())...)
 	}
 	if p.inTagContent {
-		content := p.tagContentBuffer.String()
+		if p.hasPlainEnd {
+			tagsData = append(tagsData, p.closeAtPlainEnd()...)
+			return append(tagsData, p.parseDone()...)
+		}
+		content := p.tagContentBuffer.String() + p.entities.flush()
 		if p.tagEndBuffer.Len() > 0 {
 			content += p.tagEndBuffer.String()
 		}
-		tagsData = append(tagsData, NewEndTagStreamData(p.currentTagName, p.parseAttr(), content))
+		tagsData = append(tagsData, NewEndTagStreamData(p.currentTagName, p.currentAttrs, content))
 	}
 	p.initStatus()
 	return
 }
 
+// resetStream prepares the parser for the next stream
+func (p *TagParser) resetStream() {
+	p.markdown = newMarkdownState()
+	p.instances = 0
+	p.instanceID = ""
+	p.seq = 0
+	p.contentTracker = contentTracker{}
+	p.lineBuffer = lineBuffer{}
+	p.whitespace = whitespaceState{}
+}
+
+// finish applies the options that post-process the events of a Parse, ParseDone or Flush call
+func (p *TagParser) finish(tagsData []*TagStreamData) []*TagStreamData {
+	tagsData = p.normalizeWhitespace(tagsData)
+	tagsData = p.bufferLines(tagsData)
+	p.trackContent(tagsData)
+	p.stamp(tagsData)
+	return tagsData
+}
+
+// text creates a text event, every text event goes through it so that the parser can keep
+// track of what has been emitted outside tags
+func (p *TagParser) text(s string) *TagStreamData {
+	if p.tracksText() {
+		p.markdown.write(s)
+	}
+	return NewTextTagStreamData(s)
+}
+
+// tracksText reports whether an option needs the Markdown state of the text
+func (p *TagParser) tracksText() bool {
+	return p.ignoreInMarkdownCode || p.codeBlockTag != "" || len(p.lineStartTags) > 0
+}
+
 func (p *TagParser) mergeStreams(ss []*TagStreamData) (list []*TagStreamData) {
 	for _, s := range ss {
 		if s == nil {
@@ -113,163 +260,309 @@ func (p *TagParser) mergeStreams(ss []*TagStreamData) (list []*TagStreamData) {
 			last.Content += s.Content
 			continue
 		}
+		if last.Type == TagStreamTypeAttrDelta && s.Type == last.Type &&
+			last.Attrs[0].Name == s.Attrs[0].Name {
+			last.Attrs[0].Value += s.Attrs[0].Value
+			continue
+		}
 		list = append(list, s)
 	}
 	return
 }
 
+// textStops returns the runes that may change the state of the parser in the text outside tags
+func (p *TagParser) textStops() string {
+	stops := string(p.delimiters.openRune())
+	if p.codeBlockTag != "" {
+		stops += "`~"
+	}
+	return stops
+}
+
 func (p *TagParser) parseRune(r rune) (tags []*TagStreamData) {
+	if p.codeBlock.state != codeBlockNone || (!p.inTag && p.startsCodeBlock(r)) {
+		return p.parseCodeBlockRune(r)
+	}
+
 	switch {
-	case r == '<' && !p.inTag:
+	case p.inTagName:
+		return p.parseTagNameRune(r)
+	case p.inAttr:
+		return p.parseAttrRune(r)
+	case p.inTagContent:
+		return p.parseContentRune(r)
+	case p.startsTag(r):
 		p.inTag = true
 		p.inTagName = true
-		if p.tagTotalBuffer.Len() > 0 {
-			tags = append(tags, NewTextTagStreamData(p.tagTotalBuffer.String()))
-		}
-		p.tagTotalBuffer.Reset()
 		p.tagTotalBuffer.WriteRune(r)
+		return p.provisional(r)
+	default:
+		tags = append(tags, p.text(string(r)))
 		return
-	case p.inTagName:
-		if r == '<' {
-			tags = append(tags, NewTextTagStreamData(p.tagTotalBuffer.String()))
-			p.tagTotalBuffer.Reset()
-			p.tagTotalBuffer.WriteRune(r)
-			return
+	}
+}
+
+func (p *TagParser) parseTagNameRune(r rune) (tags []*TagStreamData) {
+	p.tagTotalBuffer.WriteRune(r)
+	tags = p.provisional(r)
+	buffer := p.tagTotalBuffer.String()
+	for i, tag := range p.needParsed {
+		if !p.tagAllowed(i) {
+			continue
 		}
-		if r == ' ' {
+		switch buffer {
+		case p.startTags[i]:
+			p.currentTagName = tag
+			tags = append(tags, p.retract()...)
+			return append(tags, p.startTag(nil))
+		case p.attrStartTags[i]:
+			p.currentTagName = tag
 			p.inTagName = false
 			p.inAttr = true
-			p.parseCurrentTagName()
+			p.tagAttrBuffer.WriteRune(r)
+			tags = append(tags, p.startEarly()...)
+			return append(tags, p.hint()...)
 		}
-		if r == '>' {
-			p.inTagContent = true
-			p.inTagName = false
-			p.parseCurrentTagName()
-			p.tagTotalBuffer.WriteRune(r)
-			if !p.isNeedParseTag() {
-				tags = append(tags, NewTextTagStreamData(p.tagTotalBuffer.String()))
-				p.initStatus()
-			} else {
-				tags = append(tags, NewStartTagStreamData(p.currentTagName, nil))
-			}
-			return
+	}
+	if p.isTagCandidate(buffer) {
+		return
+	}
+	return append(tags, p.abortTag()...)
+}
+
+func (p *TagParser) parseAttrRune(r rune) (tags []*TagStreamData) {
+	p.tagTotalBuffer.WriteRune(r)
+	p.tagAttrBuffer.WriteRune(r)
+	tags = p.provisional(r)
+	// the end of the start tag may appear in a quoted value, e.g. summary="a -> b"
+	quoted := p.attrScan.state == attrQuotedValue && r != p.attrScan.quote
+	raw, ok := strings.CutSuffix(p.tagAttrBuffer.String(), p.delimiters.Close)
+	if ok && !quoted {
+		p.tagAttrBuffer.Reset()
+		p.tagAttrBuffer.WriteString(raw)
+		attrs := p.parseAttr()
+		tags = append(tags, p.finishAttrs(attrs)...)
+		tags = append(tags, p.retract()...)
+		start := p.startTag(attrs)
+		if p.earlyStarted {
+			start = NewAttrsCompleteTagStreamData(p.currentTagName, attrs)
 		}
-		if !p.tagPrefixMatch() {
-			tags = append(tags, NewTextTagStreamData(p.tagTotalBuffer.String()+string(r)))
-			p.initStatus()
-			return
+		return append(tags, start)
+	}
+	if p.tagAttrBuffer.Len() > p.maxAttrBytes {
+		tags = append(tags, p.release(p.tagTotalBuffer.String())...)
+		p.initStatus()
+		return
+	}
+	return append(tags, p.scanAttrRune(r)...)
+}
+
+// startTag opens the current tag with attrs and returns its start event
+func (p *TagParser) startTag(attrs []TagAttr) *TagStreamData {
+	p.markdown.skipTag()
+	p.inTagName = false
+	p.inAttr = false
+	p.inTagContent = true
+	p.currentAttrs = attrs
+	p.currentEndTag = p.delimiters.endTag(p.currentTagName)
+	p.guardContent(attrs)
+
+	p.currentMarkers = []string{p.currentEndTag}
+	if p.cdata {
+		p.currentMarkers = append(p.currentMarkers, cdataOpen)
+	}
+	p.currentMarkers = append(p.currentMarkers, p.guardMarkers()...)
+	return NewStartTagStreamData(p.currentTagName, attrs)
+}
+
+// abortTag releases the held start tag as text, except for its longest suffix that may still
+// become a tag
+func (p *TagParser) abortTag() (tags []*TagStreamData) {
+	buffer := p.tagTotalBuffer.String()
+	p.initStatus()
+
+	i := len(buffer)
+	for j := range buffer {
+		if j > 0 && p.isTagCandidate(buffer[j:]) {
+			i = j
+			break
 		}
-		p.tagTotalBuffer.WriteRune(r)
+	}
+	tags = append(tags, p.release(buffer[:i])...)
+	if i == len(buffer) {
 		return
-	case p.inAttr:
-		if r == '>' {
-			p.inTagContent = true
-			p.inAttr = false
-			p.tagTotalBuffer.WriteRune(r)
-			tags = append(tags, NewStartTagStreamData(p.currentTagName, p.parseAttr()))
-			return
+	}
+	if p.inMarkdownCode() {
+		return append(tags, p.release(buffer[i:])...)
+	}
+	p.inTag = true
+	p.inTagName = true
+	p.tagTotalBuffer.WriteString(buffer[i:])
+	return
+}
+
+func (p *TagParser) parseContentRune(r rune) (tags []*TagStreamData) {
+	if p.hasPlainEnd {
+		p.heldRaw.WriteRune(r)
+	}
+	// a marker ending within the declared content length is content, see WithContentLength
+	withinLength := p.contentRemaining > 0
+	if withinLength {
+		p.contentRemaining -= utf8.RuneLen(r)
+	}
+	tags = p.parseContentMarkerRune(r, withinLength)
+	if p.holdsTooLong() {
+		tags = append(tags, p.closeAtPlainEnd()...)
+	}
+	return
+}
+
+// parseContentMarkerRune matches r against the markers of the content
+func (p *TagParser) parseContentMarkerRune(r rune, withinLength bool) (tags []*TagStreamData) {
+	p.tagEndBuffer.WriteRune(r)
+	buffer := p.tagEndBuffer.String()
+	markers := p.contentMarkers()
+	for _, marker := range markers {
+		if buffer != marker {
+			continue
 		}
-		p.tagAttrBuffer.WriteRune(r)
-		p.tagTotalBuffer.WriteRune(r)
-		// 防止ai输出错误
-		if p.tagAttrBuffer.Len() > 500 {
-			tags = append(tags, NewTextTagStreamData(p.tagTotalBuffer.String()))
-			p.initStatus()
-			return
+		p.tagEndBuffer.Reset()
+		if tags, ok := p.guardedMarker(marker, withinLength); ok {
+			return tags
 		}
+		return p.contentMarker(marker)
+	}
+	if hasPrefixOf(markers, buffer) {
 		return
-	case p.inTagContent:
-		var content string
-		if r == '<' {
-			if p.tagEndBuffer.Len() == 0 {
-				p.tagEndBuffer.WriteRune(r)
-			} else {
-				content = p.tagEndBuffer.String()
-				p.tagEndBuffer.Reset()
-				p.tagEndBuffer.WriteRune(r)
-			}
+	}
+
+	// release the buffer as content, except for its longest suffix that may still become a
+	// marker
+	i := len(buffer)
+	for j := range buffer {
+		if j > 0 && hasPrefixOf(markers, buffer[j:]) {
+			i = j
+			break
 		}
-		if p.tagEndBuffer.Len() > 0 && content == "" {
-			if r != '<' {
-				p.tagEndBuffer.WriteRune(r)
-			}
-			if p.currentTagParseEnd() {
-				tags = append(
-					tags,
-					NewEndTagStreamData(
-						p.currentTagName,
-						p.parseAttr(),
-						p.tagContentBuffer.String(),
-					),
-				)
-				p.initStatus()
-				return
-			}
-			if p.tagSuffixMatch() {
-				p.tagTotalBuffer.WriteRune(r)
-				return
-			}
-			if !p.tagSuffixMatch() {
-				content = p.tagEndBuffer.String()
-				p.tagEndBuffer.Reset()
-			}
+	}
+	content := buffer[:i]
+	p.tagEndBuffer.Reset()
+	p.tagEndBuffer.WriteString(buffer[i:])
+	return append(tags, p.content(content))
+}
+
+// content creates a content event for the current tag, nil if everything is held back
+func (p *TagParser) content(s string) *TagStreamData {
+	switch {
+	case !p.decodeContentEntities:
+	case p.inCDATA:
+		// character references are not decoded inside CDATA sections
+		s = p.entities.flush() + s
+	default:
+		if s = p.entities.write(s); s == "" {
+			return nil
 		}
+	}
+	return p.appendContent(s)
+}
 
-		p.tagTotalBuffer.WriteRune(r)
-		p.tagContentBuffer.WriteString(cmp.Or(content, string(r)))
-		tags = append(tags, NewContentTagStreamData(p.currentTagName, cmp.Or(content, string(r))))
-		return
+func (p *TagParser) appendContent(s string) *TagStreamData {
+	p.tagContentBuffer.WriteString(s)
+	if p.hasPlainEnd {
+		return nil
+	}
+	return NewContentTagStreamData(p.currentTagName, s)
+}
+
+// contentMarkers returns what is held back in the content of the current tag until it either
+// matches or cannot match anymore
+func (p *TagParser) contentMarkers() []string {
+	if p.inCDATA {
+		return cdataCloseMarkers
+	}
+	return p.currentMarkers
+}
+
+func (p *TagParser) contentMarker(marker string) (tags []*TagStreamData) {
+	switch marker {
+	case cdataOpen:
+		p.inCDATA = true
+		if !p.stripCDATA {
+			tags = append(tags, p.content(marker))
+		}
+	case cdataClose:
+		p.inCDATA = false
+		p.entities.pending.Reset()
+		if !p.stripCDATA {
+			tags = append(tags, p.content(marker))
+		}
 	default:
-		tags = append(tags, NewTextTagStreamData(string(r)))
-		return
+		p.markdown.skipTag()
+		if tail := p.entities.flush(); tail != "" {
+			tags = append(tags, p.appendContent(tail))
+		}
+		tags = append(tags, p.releaseHeld())
+		tags = append(
+			tags,
+			NewEndTagStreamData(p.currentTagName, p.currentAttrs, p.tagContentBuffer.String()),
+		)
+		p.initStatus()
 	}
+	return
 }
 
-func (p *TagParser) tagPrefixMatch() bool {
-	for _, tag := range p.needParsed {
-		if strings.HasPrefix("<"+tag, p.tagTotalBuffer.String()) {
+// hasPrefixOf reports whether s is the beginning of any of markers
+func hasPrefixOf(markers []string, s string) bool {
+	for _, marker := range markers {
+		if strings.HasPrefix(marker, s) {
 			return true
 		}
 	}
 	return false
 }
 
-func (p *TagParser) tagSuffixMatch() bool {
-	return strings.HasPrefix("</"+p.currentTagName, p.tagEndBuffer.String())
+// startsTag reports whether r may begin a start tag in the text
+func (p *TagParser) startsTag(r rune) bool {
+	p.tagAtLineStart = p.markdown.lineStart
+	return p.isTagCandidate(string(r)) && !p.inMarkdownCode()
 }
 
-func (p *TagParser) parseCurrentTagName() {
-	if p.currentTagName != "" {
-		return
-	}
-	if p.tagTotalBuffer.Len() <= 1 {
-		return
-	}
-	p.currentTagName = p.tagTotalBuffer.String()[1:]
+// tagAllowed reports whether the i-th needed tag may start where the current start tag began
+func (p *TagParser) tagAllowed(i int) bool {
+	return p.tagAtLineStart || !p.lineStartTags[p.needParsed[i]]
 }
 
-func (p *TagParser) isNeedParseTag() bool {
-	return slices.Contains(p.needParsed, p.currentTagName)
+// isTagCandidate reports whether s is the beginning of a start tag of a needed tag
+func (p *TagParser) isTagCandidate(s string) bool {
+	for i := range p.needParsed {
+		if !p.tagAllowed(i) {
+			continue
+		}
+		if strings.HasPrefix(p.startTags[i], s) || strings.HasPrefix(p.attrStartTags[i], s) {
+			return true
+		}
+	}
+	return false
 }
 
-func (p *TagParser) currentTagParseEnd() bool {
-	return fmt.Sprintf("</%s>", p.currentTagName) == p.tagEndBuffer.String()
+func (p *TagParser) inMarkdownCode() bool {
+	if !p.ignoreInMarkdownCode || !p.markdown.inCode() {
+		return false
+	}
+	// a code span still open where a line begins with a tag is taken for a stray backtick,
+	// the tag closes it
+	return p.markdown.fence > 0 || !p.markdown.lineStart
 }
 
 func (p *TagParser) parseAttr() (tags []TagAttr) {
 	if p.tagAttrBuffer.Len() == 0 {
 		return nil
 	}
-	attrs := strings.Split(p.tagAttrBuffer.String(), " ")
-	for _, attr := range attrs {
-		kv := strings.Split(attr, "=")
-		if len(kv) != 2 {
-			continue
+	tags = parseAttrs(p.tagAttrBuffer.String())
+	if p.decodeAttrEntities {
+		for i := range tags {
+			tags[i].Value = html.UnescapeString(tags[i].Value)
 		}
-		tags = append(tags, TagAttr{
-			Name:  strings.TrimSuffix(strings.TrimPrefix(kv[0], `"`), `"`),
-			Value: strings.TrimSuffix(strings.TrimPrefix(kv[1], `"`), `"`),
-		})
 	}
 	return
 }
@@ -432,8 +432,10 @@ func TestTagParser(t *testing.T) {
 }
 
 func testParserTest(t *testing.T, testData parserTest, tags ...string) {
-	parser := NewTagParser(tags...)
+	testParser(t, testData, NewTagParser(tags...))
+}
 
+func testParser(t *testing.T, testData parserTest, parser *TagParser) {
 	for _, item := range testData.items {
 		tags := parser.Parse(item.input)
 		if len(item.expectedTags) != len(tags) {
@@ -471,6 +473,9 @@ func tagEqual(t *testing.T, expected, actual *TagStreamData) {
 	if expected.Content != actual.Content {
 		t.Fatalf("expected content: %s, got: %s", expected.Content, actual.Content)
 	}
+	if expected.Retract != actual.Retract {
+		t.Fatalf("expected retract: %d, got: %d", expected.Retract, actual.Retract)
+	}
 	if len(expected.Attrs) != len(actual.Attrs) {
 		t.Fatalf("expected attrs length: %d, got: %d", len(expected.Attrs), len(actual.Attrs))
 	}
@@ -0,0 +1,153 @@
+package streamtagparser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// RecordedChunk is one Parse call, or the final ParseDone call, of a recorded session
+type RecordedChunk struct {
+	// Offset is the time since the recorder was created
+	Offset time.Duration    `json:"offset"`
+	Chunk  string           `json:"chunk,omitempty"`
+	Done   bool             `json:"done,omitempty"`
+	Events []*TagStreamData `json:"events"`
+}
+
+// Recorder wraps a TagParser and captures the raw input chunks next to the events they
+// produced, so that the session can be replayed through a fresh TagParser and diffed.
+// Non-concurrency safe, like the TagParser it wraps
+type Recorder struct {
+	parser *TagParser
+	now    func() time.Time
+	start  time.Time
+	chunks []RecordedChunk
+}
+
+func NewRecorder(parser *TagParser) *Recorder {
+	r := &Recorder{
+		parser: parser,
+		now:    time.Now,
+	}
+	r.start = r.now()
+	return r
+}
+
+func (r *Recorder) Parse(streamStr string) []*TagStreamData {
+	tagsData := r.parser.Parse(streamStr)
+	r.chunks = append(r.chunks, RecordedChunk{
+		Offset: r.now().Sub(r.start),
+		Chunk:  streamStr,
+		Events: tagsData,
+	})
+	return tagsData
+}
+
+func (r *Recorder) ParseDone() []*TagStreamData {
+	tagsData := r.parser.ParseDone()
+	r.chunks = append(r.chunks, RecordedChunk{
+		Offset: r.now().Sub(r.start),
+		Done:   true,
+		Events: tagsData,
+	})
+	return tagsData
+}
+
+// Chunks returns the recorded session so far
+func (r *Recorder) Chunks() []RecordedChunk {
+	return r.chunks
+}
+
+// WriteSession writes chunks as JSON Lines, one chunk per line
+func WriteSession(w io.Writer, chunks []RecordedChunk) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, chunk := range chunks {
+		if err := enc.Encode(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadSession reads a session written by WriteSession
+func ReadSession(r io.Reader) (chunks []RecordedChunk, err error) {
+	dec := json.NewDecoder(r)
+	for {
+		var chunk RecordedChunk
+		if err := dec.Decode(&chunk); err != nil {
+			if errors.Is(err, io.EOF) {
+				return chunks, nil
+			}
+			return nil, fmt.Errorf("read chunk %d: %w", len(chunks), err)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+// Replay feeds the recorded chunks through parser and returns them with the new events,
+// offsets are copied but not waited for
+func Replay(parser *TagParser, chunks []RecordedChunk) []RecordedChunk {
+	replayed := make([]RecordedChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.Done {
+			chunk.Events = parser.ParseDone()
+		} else {
+			chunk.Events = parser.Parse(chunk.Chunk)
+		}
+		replayed = append(replayed, chunk)
+	}
+	return replayed
+}
+
+// SessionDiff is a chunk whose events differ between two sessions
+type SessionDiff struct {
+	Index    int
+	Chunk    RecordedChunk
+	Expected []*TagStreamData
+	Actual   []*TagStreamData
+}
+
+func (d SessionDiff) String() string {
+	expected, _ := json.Marshal(d.Expected)
+	actual, _ := json.Marshal(d.Actual)
+	return fmt.Sprintf("chunk %d %q:\n  expected: %s\n  actual:   %s",
+		d.Index, d.Chunk.Chunk, expected, actual)
+}
+
+// DiffSession compares the events of a recorded session with a replayed one chunk by chunk
+func DiffSession(recorded, replayed []RecordedChunk) (diffs []SessionDiff) {
+	for i := range max(len(recorded), len(replayed)) {
+		var diff SessionDiff
+		diff.Index = i
+		if i < len(replayed) {
+			diff.Chunk = replayed[i]
+			diff.Actual = replayed[i].Events
+		}
+		if i < len(recorded) {
+			diff.Chunk = recorded[i]
+			diff.Expected = recorded[i].Events
+		}
+		if eventsEqual(diff.Expected, diff.Actual) {
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	return
+}
+
+func eventsEqual(a, b []*TagStreamData) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !reflect.DeepEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package streamtagparser
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLCodec(t *testing.T) {
+	parser := NewTagParser("Artifact")
+	tagsData := parser.Parse(`a <Artifact id="1">x<y</Artifact>`)
+
+	var buf bytes.Buffer
+	enc := NewJSONLEncoder(&buf)
+	for _, data := range tagsData {
+		if err := enc.Encode(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != len(tagsData) {
+		t.Fatalf("expected %d lines, got: %d", len(tagsData), lines)
+	}
+	if !strings.Contains(buf.String(), `"content":"x<y"`) {
+		t.Fatalf("expected unescaped content, got: %s", buf.String())
+	}
+
+	dec := NewJSONLDecoder(&buf)
+	for _, expected := range tagsData {
+		data, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tagEqual(t, expected, data)
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got: %v", err)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder(NewTagParser("Artifact"))
+	now := rec.start
+	rec.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	rec.Parse("hello <Arti")
+	rec.Parse("fact>abc</Artifact>")
+	rec.ParseDone()
+
+	var buf bytes.Buffer
+	if err := WriteSession(&buf, rec.Chunks()); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := ReadSession(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 || !chunks[2].Done || chunks[1].Offset != 2*time.Second {
+		t.Fatalf("unexpected session: %+v", chunks)
+	}
+
+	if diffs := DiffSession(chunks, Replay(NewTagParser("Artifact"), chunks)); len(diffs) != 0 {
+		t.Fatalf("expected no diffs, got: %v", diffs)
+	}
+
+	diffs := DiffSession(chunks, Replay(NewTagParser("Think"), chunks))
+	if len(diffs) != 2 || diffs[0].Index != 0 || diffs[1].Index != 1 {
+		t.Fatalf("expected diffs for the first two chunks, got: %v", diffs)
+	}
+	if diffs[1].Chunk.Chunk != "fact>abc</Artifact>" {
+		t.Fatalf("unexpected diff chunk: %q", diffs[1].Chunk.Chunk)
+	}
+}
@@ -0,0 +1,157 @@
+package streamtagparser
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// abbreviations end with a period that does not end a sentence, lower case without the last
+// period
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true, "jr": true,
+	"st": true, "vs": true, "etc": true, "e.g": true, "i.e": true, "inc": true, "ltd": true,
+	"co": true, "no": true, "fig": true, "approx": true, "u.s": true, "a.m": true, "p.m": true,
+}
+
+type sentenceStop int
+
+const (
+	sentenceStopNone sentenceStop = iota
+	// a period, question or exclamation mark ends the sentence if whitespace follows
+	sentenceStopLatin
+	// CJK punctuation ends the sentence right away, after any closing quotes or brackets
+	sentenceStopCJK
+)
+
+// SentenceSegmenter wraps a TagParser and re-cuts its text events at sentence boundaries,
+// e.g. to feed text-to-speech, while tag events pass through untouched. A sentence ends after
+// . ! ? or … followed by whitespace, unless the period follows an initial or a common
+// abbreviation, after CJK 。！？ right away, and at every newline, so a decimal point never
+// ends a sentence. Closing quotes and brackets stay with their sentence. Text held back for
+// the rest of its sentence is flushed before every other event and at the end of the stream.
+// Non-concurrency safe, like the TagParser it wraps
+type SentenceSegmenter struct {
+	parser *TagParser
+
+	sentence strings.Builder
+	stop     sentenceStop
+	stopAt   int // length of the sentence before its stop
+}
+
+func NewSentenceSegmenter(parser *TagParser) *SentenceSegmenter {
+	return &SentenceSegmenter{parser: parser}
+}
+
+func (s *SentenceSegmenter) Parse(streamStr string) []*TagStreamData {
+	return s.segment(s.parser.Parse(streamStr))
+}
+
+func (s *SentenceSegmenter) ParseDone() []*TagStreamData {
+	tagsData := s.segment(s.parser.ParseDone())
+	return append(tagsData, s.flush()...)
+}
+
+func (s *SentenceSegmenter) segment(tagsData []*TagStreamData) (list []*TagStreamData) {
+	for _, data := range tagsData {
+		switch data.Type {
+		case TagStreamTypeText:
+			for _, r := range data.Text {
+				list = append(list, s.writeRune(r)...)
+			}
+		case TagStreamTypeRetract:
+			// take back what is still held, see WithSpeculativeTags
+			if data = s.retract(data.Retract); data != nil {
+				list = append(list, data)
+			}
+		default:
+			list = append(list, s.flush()...)
+			list = append(list, data)
+		}
+	}
+	return
+}
+
+// writeRune adds r to the sentence and returns the sentences it completes
+func (s *SentenceSegmenter) writeRune(r rune) (tags []*TagStreamData) {
+	switch s.stop {
+	case sentenceStopLatin:
+		switch {
+		case isSentenceCloser(r):
+			s.sentence.WriteRune(r)
+			return nil
+		case unicode.IsSpace(r) && !s.abbreviated():
+			s.sentence.WriteRune(r)
+			return s.flush()
+		}
+		s.stop = sentenceStopNone
+	case sentenceStopCJK:
+		if isSentenceCloser(r) {
+			s.sentence.WriteRune(r)
+			return nil
+		}
+		tags = s.flush()
+	}
+
+	s.stopAt = s.sentence.Len()
+	s.sentence.WriteRune(r)
+	switch r {
+	case '.', '!', '?', '…':
+		s.stop = sentenceStopLatin
+	case '。', '！', '？':
+		s.stop = sentenceStopCJK
+	case '\n':
+		tags = append(tags, s.flush()...)
+	}
+	return
+}
+
+// abbreviated reports whether the period that stops the sentence ends an initial or an
+// abbreviation rather than the sentence
+func (s *SentenceSegmenter) abbreviated() bool {
+	sentence := s.sentence.String()
+	if sentence[s.stopAt] != '.' {
+		return false
+	}
+	before := sentence[:s.stopAt]
+	word := before[strings.LastIndexFunc(before, unicode.IsSpace)+1:]
+	word = strings.TrimLeft(word, `"'([“‘`)
+	if word == "" {
+		return false
+	}
+	if r, size := utf8.DecodeRuneInString(word); size == len(word) && unicode.IsLetter(r) {
+		return true
+	}
+	return abbreviations[strings.ToLower(word)]
+}
+
+// retract takes back n runes of the held sentence and returns a retract event for the rest
+func (s *SentenceSegmenter) retract(n int) *TagStreamData {
+	runes := []rune(s.sentence.String())
+	kept := runes[:max(len(runes)-n, 0)]
+	s.sentence.Reset()
+	s.stop = sentenceStopNone
+	// held text never completes a sentence, writing it again only restores the stop
+	for _, r := range kept {
+		s.writeRune(r)
+	}
+	if n -= len(runes) - len(kept); n > 0 {
+		return NewRetractTagStreamData(n)
+	}
+	return nil
+}
+
+// flush emits the held sentence
+func (s *SentenceSegmenter) flush() []*TagStreamData {
+	s.stop = sentenceStopNone
+	if s.sentence.Len() == 0 {
+		return nil
+	}
+	data := NewTextTagStreamData(s.sentence.String())
+	s.sentence.Reset()
+	return []*TagStreamData{data}
+}
+
+func isSentenceCloser(r rune) bool {
+	return strings.ContainsRune(`"')]”’」』）】`, r)
+}
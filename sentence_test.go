@@ -0,0 +1,76 @@
+package streamtagparser
+
+import "testing"
+
+func TestSentenceSegmenter(t *testing.T) {
+	t.Run("sentences", func(t *testing.T) {
+		s := NewSentenceSegmenter(NewTagParser("Artifact"))
+		var got []string
+		chunks := []string{
+			"Hi Mr. Smith, pi is 3.",
+			"14! Really?",
+			" \"Yes.\" See e.g. J. Doe",
+			" 你好。真的吗？」好\nok",
+		}
+		for _, chunk := range chunks {
+			for _, data := range s.Parse(chunk) {
+				got = append(got, data.Text)
+			}
+		}
+		for _, data := range s.ParseDone() {
+			got = append(got, data.Text)
+		}
+		expected := []string{
+			"Hi Mr. Smith, pi is 3.14! ",
+			"Really? ",
+			"\"Yes.\" ",
+			"See e.g. J. Doe 你好。",
+			"真的吗？」",
+			"好\n",
+			"ok",
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("expected %q, got: %q", expected, got)
+		}
+		for i := range got {
+			if got[i] != expected[i] {
+				t.Fatalf("expected %q, got: %q", expected, got)
+			}
+		}
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		s := NewSentenceSegmenter(NewTagParser("Artifact"))
+		got := s.Parse("Look. Here it is<Artifact>Not. Spoken.")
+		expected := []*TagStreamData{
+			{Type: TagStreamTypeText, Text: "Look. "},
+			{Type: TagStreamTypeText, Text: "Here it is"},
+			{Type: TagStreamTypeStart, TagName: "Artifact"},
+			{Type: TagStreamTypeContent, TagName: "Artifact", Content: "Not. Spoken."},
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("expected %d events, got: %d", len(expected), len(got))
+		}
+		for i := range got {
+			tagEqual(t, expected[i], got[i])
+		}
+	})
+
+	t.Run("retract held text", func(t *testing.T) {
+		parser := NewTagParserWithOptions([]string{"Artifact"}, WithSpeculativeTags())
+		s := NewSentenceSegmenter(parser)
+		got := s.Parse("Done. See <Art")
+		got = append(got, s.Parse("ifact>")...)
+		expected := []*TagStreamData{
+			{Type: TagStreamTypeText, Text: "Done. "},
+			{Type: TagStreamTypeText, Text: "See "},
+			{Type: TagStreamTypeStart, TagName: "Artifact"},
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("expected %d events, got: %d", len(expected), len(got))
+		}
+		for i := range got {
+			tagEqual(t, expected[i], got[i])
+		}
+	})
+}
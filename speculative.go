@@ -0,0 +1,48 @@
+package streamtagparser
+
+import "unicode/utf8"
+
+// WithSpeculativeTags emits a possible start tag as text right away instead of holding it
+// back. If it turns out to be a start tag, a retract event telling how many runes of the text
+// to take back precedes the start event, e.g. "a <Art" then "ifact>" emits the texts "a <Art"
+// and "ifact>", a retract of 10 and the start of Artifact
+func WithSpeculativeTags() Option {
+	return func(p *TagParser) {
+		p.speculative = true
+	}
+}
+
+// provisional emits r of a possible start tag as text in speculative mode
+func (p *TagParser) provisional(r rune) []*TagStreamData {
+	if !p.speculative || p.earlyStarted {
+		return nil
+	}
+	return []*TagStreamData{NewTextTagStreamData(string(r))}
+}
+
+// release emits s of a held start tag that turned out to be text, which speculative mode
+// already did
+func (p *TagParser) release(s string) (tags []*TagStreamData) {
+	tags = p.withdrawHint()
+	if p.earlyStarted && p.inAttr {
+		// the start tag was taken back as a whole
+		tags = append(tags, NewEndTagStreamData(p.currentTagName, nil, ""))
+		return append(tags, p.text(s))
+	}
+	if !p.speculative {
+		return append(tags, p.text(s))
+	}
+	if p.tracksText() {
+		p.markdown.write(s)
+	}
+	return
+}
+
+// retract takes back the provisional text of the start tag in speculative mode
+func (p *TagParser) retract() []*TagStreamData {
+	if !p.speculative || p.earlyStarted {
+		return nil
+	}
+	n := utf8.RuneCountInString(p.tagTotalBuffer.String())
+	return []*TagStreamData{NewRetractTagStreamData(n)}
+}
@@ -0,0 +1,75 @@
+package streamtagparser
+
+import "testing"
+
+func TestSpeculativeTags(t *testing.T) {
+	newParser := func() *TagParser {
+		return NewTagParserWithOptions([]string{"Artifact"}, WithSpeculativeTags())
+	}
+
+	t.Run("retract start tag", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input:        "a <Art",
+					expectedTags: []*TagStreamData{{Type: TagStreamTypeText, Text: "a <Art"}},
+				},
+				{
+					input: `ifact id="1">b</Artifact>`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: `ifact id="1">`},
+						{Type: TagStreamTypeRetract, Retract: 17},
+						{
+							Type:    TagStreamTypeStart,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "id", Value: "1"}},
+						},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "b"},
+						{
+							Type:    TagStreamTypeEnd,
+							TagName: "Artifact",
+							Attrs:   []TagAttr{{Name: "id", Value: "1"}},
+							Content: "b",
+						},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("aborted prefix is not repeated", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input:        "<Arti",
+					expectedTags: []*TagStreamData{{Type: TagStreamTypeText, Text: "<Arti"}},
+				},
+				{
+					input: "<<Artifact>",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeText, Text: "<<Artifact>"},
+						{Type: TagStreamTypeRetract, Retract: 10},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "Artifact"},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("held prefix at the end", func(t *testing.T) {
+		parser := newParser()
+		parser.Parse("<Art")
+		if got := parser.Flush(); len(got) != 0 {
+			t.Fatalf("expected nothing to flush, got: %d events", len(got))
+		}
+		parser.Parse("<Art")
+		if got := parser.ParseDone(); len(got) != 0 {
+			t.Fatalf("expected no events, got: %d", len(got))
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package streamtagparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSSEHeartbeatInterval is the heartbeat interval used by NewSSEHandler
+const DefaultSSEHeartbeatInterval = 15 * time.Second
+
+// SSEEncoder writes TagStreamData as Server-Sent Events frames.
+// Non-concurrency safe, an SSEEncoder can only be used for one stream
+type SSEEncoder struct {
+	w       io.Writer
+	flusher http.Flusher
+	buf     bytes.Buffer
+	lastID  uint64
+}
+
+func NewSSEEncoder(w io.Writer) *SSEEncoder {
+	e := &SSEEncoder{w: w}
+	if f, ok := w.(http.Flusher); ok {
+		e.flusher = f
+	}
+	return e
+}
+
+// Encode writes data as one frame, the event name is data.Type and the id increases by one
+// for every frame
+func (e *SSEEncoder) Encode(data *TagStreamData) error {
+	return e.EncodeWithID(e.lastID+1, data)
+}
+
+// EncodeWithID writes data with the given id, e.g. when replaying an EventLog.
+// Later Encode calls continue counting from id
+func (e *SSEEncoder) EncodeWithID(id uint64, data *TagStreamData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	e.lastID = id
+
+	e.buf.Reset()
+	e.buf.WriteString("id: ")
+	e.buf.WriteString(strconv.FormatUint(id, 10))
+	e.buf.WriteString("\nevent: ")
+	e.buf.WriteString(string(data.Type))
+	e.buf.WriteString("\ndata: ")
+	e.buf.Write(payload)
+	e.buf.WriteString("\n\n")
+	return e.write()
+}
+
+// Heartbeat writes a comment frame, which keeps proxies from closing an idle connection
+func (e *SSEEncoder) Heartbeat() error {
+	e.buf.Reset()
+	e.buf.WriteString(": heartbeat\n\n")
+	return e.write()
+}
+
+// LastID returns the id of the last frame written by Encode
+func (e *SSEEncoder) LastID() uint64 {
+	return e.lastID
+}
+
+func (e *SSEEncoder) write() error {
+	if _, err := e.w.Write(e.buf.Bytes()); err != nil {
+		return err
+	}
+	if e.flusher != nil {
+		e.flusher.Flush()
+	}
+	return nil
+}
+
+// LastEventID returns the Last-Event-ID a reconnecting browser sent with r
+func LastEventID(r *http.Request) (id uint64, ok bool) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// ChunkSource opens the upstream stream for r. The returned channel delivers the raw chunks
+// and must be closed once the upstream is done, the source should stop sending when
+// r.Context() is cancelled.
+type ChunkSource func(r *http.Request) (<-chan string, error)
+
+// SSEHandler parses the chunks of a ChunkSource and serves the events as Server-Sent Events
+type SSEHandler struct {
+	source    ChunkSource
+	newParser func() *TagParser
+
+	// HeartbeatInterval is the interval of heartbeat comments, zero disables them
+	HeartbeatInterval time.Duration
+}
+
+// NewSSEHandler newParser is called once per request, as a TagParser can only be used for one
+// stream
+func NewSSEHandler(source ChunkSource, newParser func() *TagParser) *SSEHandler {
+	return &SSEHandler{
+		source:            source,
+		newParser:         newParser,
+		HeartbeatInterval: DefaultSSEHeartbeatInterval,
+	}
+}
+
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	chunks, err := h.source(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	enc := NewSSEEncoder(w)
+	parser := h.newParser()
+
+	var heartbeat <-chan time.Time
+	if h.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(h.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat:
+			if err := enc.Heartbeat(); err != nil {
+				return
+			}
+		case chunk, ok := <-chunks:
+			if !ok {
+				_ = encodeAll(enc, parser.ParseDone())
+				return
+			}
+			if err := encodeAll(enc, parser.Parse(chunk)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func encodeAll(enc *SSEEncoder, tagsData []*TagStreamData) error {
+	for _, data := range tagsData {
+		if err := enc.Encode(data); err != nil {
+			return fmt.Errorf("encode %s event: %w", data.Type, err)
+		}
+	}
+	return nil
+}
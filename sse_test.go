@@ -0,0 +1,75 @@
+package streamtagparser
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSSEEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewSSEEncoder(&buf)
+
+	if err := enc.Encode(NewTextTagStreamData("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Heartbeat(); err != nil {
+		t.Fatal(err)
+	}
+	err := enc.Encode(NewStartTagStreamData("Artifact", []TagAttr{{Name: "id", Value: "1"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "id: 1\nevent: text\ndata: {\"type\":\"text\",\"text\":\"hello\"}\n\n" +
+		": heartbeat\n\n" +
+		"id: 2\nevent: start\ndata: " +
+		`{"type":"start","tag_name":"Artifact","attrs":[{"name":"id","value":"1"}]}` + "\n\n"
+	if buf.String() != expected {
+		t.Fatalf("expected: %q, got: %q", expected, buf.String())
+	}
+	if enc.LastID() != 2 {
+		t.Fatalf("expected last id: 2, got: %d", enc.LastID())
+	}
+}
+
+func TestSSEHandler(t *testing.T) {
+	source := func(r *http.Request) (<-chan string, error) {
+		ch := make(chan string, 3)
+		ch <- "hi <Arti"
+		ch <- "fact>x</Artifact"
+		ch <- "> <Artifact>y"
+		close(ch)
+		return ch, nil
+	}
+	handler := NewSSEHandler(source, func() *TagParser {
+		return NewTagParser("Artifact")
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected content type: text/event-stream, got: %s", ct)
+	}
+	if !rec.Flushed {
+		t.Fatal("expected response to be flushed")
+	}
+
+	expected := "id: 1\nevent: text\ndata: {\"type\":\"text\",\"text\":\"hi \"}\n\n" +
+		"id: 2\nevent: start\ndata: {\"type\":\"start\",\"tag_name\":\"Artifact\"}\n\n" +
+		"id: 3\nevent: content\ndata: " +
+		`{"type":"content","tag_name":"Artifact","content":"x"}` + "\n\n" +
+		"id: 4\nevent: end\ndata: " +
+		`{"type":"end","tag_name":"Artifact","content":"x"}` + "\n\n" +
+		"id: 5\nevent: text\ndata: {\"type\":\"text\",\"text\":\" \"}\n\n" +
+		"id: 6\nevent: start\ndata: {\"type\":\"start\",\"tag_name\":\"Artifact\"}\n\n" +
+		"id: 7\nevent: content\ndata: " +
+		`{"type":"content","tag_name":"Artifact","content":"y"}` + "\n\n" +
+		"id: 8\nevent: end\ndata: " +
+		`{"type":"end","tag_name":"Artifact","content":"y"}` + "\n\n"
+	if rec.Body.String() != expected {
+		t.Fatalf("expected: %q, got: %q", expected, rec.Body.String())
+	}
+}
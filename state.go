@@ -0,0 +1,79 @@
+package streamtagparser
+
+import (
+	"html"
+	"slices"
+)
+
+// ParserMode is what a TagParser is in the middle of, see TagParser.State
+type ParserMode string
+
+const (
+	// ParserModeText is outside tags, it is safe to cut the stream here
+	ParserModeText ParserMode = "text"
+	// ParserModeTagName holds back what may become a start tag or an opening code fence
+	ParserModeTagName ParserMode = "tag_name"
+	// ParserModeAttrs is in the attributes of a start tag whose name is known
+	ParserModeAttrs ParserMode = "attrs"
+	// ParserModeContent is in the content of an open tag or code block
+	ParserModeContent ParserMode = "content"
+)
+
+// ParserState is a snapshot of a TagParser
+type ParserState struct {
+	Mode ParserMode `json:"mode"`
+
+	// TagName and Attrs are those of the open tag in ParserModeContent, and of the start tag
+	// being parsed in ParserModeAttrs, with the attributes completed so far
+	TagName string    `json:"tag_name,omitempty"`
+	Attrs   []TagAttr `json:"attrs,omitempty"`
+
+	HeldBytes     int `json:"held_bytes"`     // bytes held back until they are disambiguated
+	ContentLength int `json:"content_length"` // bytes of content of the open tag so far
+}
+
+// State returns a snapshot of the parser, e.g. to only cut the stream outside tags
+func (p *TagParser) State() ParserState {
+	state := ParserState{
+		Mode:      ParserModeText,
+		HeldBytes: p.held(),
+	}
+	c := &p.codeBlock
+	switch {
+	case c.state == codeBlockOpening:
+		state.Mode = ParserModeTagName
+	case c.state == codeBlockContent:
+		state.Mode = ParserModeContent
+		state.TagName = p.codeBlockTag
+		state.Attrs = slices.Clone(c.attrs)
+		state.ContentLength = c.content.Len()
+	case p.inTagName:
+		state.Mode = ParserModeTagName
+	case p.inAttr:
+		state.Mode = ParserModeAttrs
+		state.TagName = p.currentTagName
+		state.Attrs = p.completedAttrs()
+	case p.inTagContent:
+		state.Mode = ParserModeContent
+		state.TagName = p.currentTagName
+		state.Attrs = slices.Clone(p.currentAttrs)
+		state.ContentLength = p.tagContentBuffer.Len() - p.heldContent()
+	}
+	return state
+}
+
+// completedAttrs returns the attributes of the start tag being parsed that are complete
+func (p *TagParser) completedAttrs() (attrs []TagAttr) {
+	var scanner attrScanner
+	for _, r := range p.tagAttrBuffer.String() {
+		attr, ok := scanner.writeRune(r)
+		if !ok {
+			continue
+		}
+		if p.decodeAttrEntities {
+			attr.Value = html.UnescapeString(attr.Value)
+		}
+		attrs = append(attrs, attr)
+	}
+	return
+}
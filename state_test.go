@@ -0,0 +1,65 @@
+package streamtagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	parser := NewTagParserWithOptions([]string{"Artifact"}, WithCodeBlocks("code"))
+	steps := []struct {
+		input    string
+		expected ParserState
+	}{
+		{
+			input:    "a ",
+			expected: ParserState{Mode: ParserModeText},
+		},
+		{
+			input:    "<Arti",
+			expected: ParserState{Mode: ParserModeTagName, HeldBytes: 5},
+		},
+		{
+			input: `fact id="1" t`,
+			expected: ParserState{
+				Mode:      ParserModeAttrs,
+				TagName:   "Artifact",
+				Attrs:     []TagAttr{{Name: "id", Value: "1"}},
+				HeldBytes: 18,
+			},
+		},
+		{
+			input: `="2">abc</Art`,
+			expected: ParserState{
+				Mode:    ParserModeContent,
+				TagName: "Artifact",
+				Attrs: []TagAttr{
+					{Name: "id", Value: "1"},
+					{Name: "t", Value: "2"},
+				},
+				HeldBytes:     5,
+				ContentLength: 3,
+			},
+		},
+		{
+			input:    "ifact>\n",
+			expected: ParserState{Mode: ParserModeText},
+		},
+		{
+			input: "```go\nx\n``",
+			expected: ParserState{
+				Mode:          ParserModeContent,
+				TagName:       "code",
+				Attrs:         []TagAttr{{Name: "lang", Value: "go"}},
+				HeldBytes:     2,
+				ContentLength: 2,
+			},
+		},
+	}
+	for _, step := range steps {
+		parser.Parse(step.input)
+		if got := parser.State(); !reflect.DeepEqual(got, step.expected) {
+			t.Fatalf("after %q expected state: %+v, got: %+v", step.input, step.expected, got)
+		}
+	}
+}
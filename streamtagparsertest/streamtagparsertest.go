@@ -0,0 +1,171 @@
+// Package streamtagparsertest provides helpers for checking that the events of a parser do not
+// depend on where the provider splits the stream.
+package streamtagparsertest
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/liushuangls/streamtagparser"
+)
+
+// Parser is implemented by *streamtagparser.TagParser and by the wrappers around it
+type Parser interface {
+	Parse(streamStr string) []*streamtagparser.TagStreamData
+	ParseDone() []*streamtagparser.TagStreamData
+}
+
+// DefaultMarkers are the substrings SplitAdversarial cuts around
+var DefaultMarkers = []string{"<", "</", ">"}
+
+// SplitEveryRune returns one chunk per rune
+func SplitEveryRune(s string) (chunks []string) {
+	for i, r := range s {
+		chunks = append(chunks, s[i:i+utf8.RuneLen(r)])
+	}
+	return
+}
+
+// SplitRandom returns chunks of 1 to maxSize runes
+func SplitRandom(s string, rnd *rand.Rand, maxSize int) (chunks []string) {
+	runes := []rune(s)
+	for len(runes) > 0 {
+		size := min(1+rnd.IntN(max(maxSize, 1)), len(runes))
+		chunks = append(chunks, string(runes[:size]))
+		runes = runes[size:]
+	}
+	return
+}
+
+// SplitAdversarial returns chunkings that cut s right before, inside and right after every
+// occurrence of the markers, DefaultMarkers if none are given. Each cut position gets its
+// own two-chunk split, the last chunking cuts at all positions at once
+func SplitAdversarial(s string, markers ...string) (chunkings [][]string) {
+	if len(markers) == 0 {
+		markers = DefaultMarkers
+	}
+	var cuts []int
+	for _, marker := range markers {
+		for i := 0; ; {
+			j := strings.Index(s[i:], marker)
+			if j == -1 {
+				break
+			}
+			start := i + j
+			for k := start; k <= start+len(marker); k++ {
+				if k > 0 && k < len(s) && utf8.RuneStart(s[k]) {
+					cuts = append(cuts, k)
+				}
+			}
+			i = start + 1
+		}
+	}
+	slices.Sort(cuts)
+	cuts = slices.Compact(cuts)
+
+	for _, cut := range cuts {
+		chunkings = append(chunkings, []string{s[:cut], s[cut:]})
+	}
+	if len(cuts) > 1 {
+		var chunks []string
+		last := 0
+		for _, cut := range cuts {
+			chunks = append(chunks, s[last:cut])
+			last = cut
+		}
+		chunkings = append(chunkings, append(chunks, s[last:]))
+	}
+	return
+}
+
+// Run feeds chunks to parser and returns every event including those of ParseDone
+func Run(parser Parser, chunks []string) (tagsData []*streamtagparser.TagStreamData) {
+	for _, chunk := range chunks {
+		tagsData = append(tagsData, parser.Parse(chunk)...)
+	}
+	return append(tagsData, parser.ParseDone()...)
+}
+
+// Normalize merges consecutive text events, consecutive content events of the same tag and
+// consecutive attr_delta events of the same attribute, so that sequences produced from
+// different chunkings can be compared. Seq is cleared. The input is not modified
+func Normalize(tagsData []*streamtagparser.TagStreamData) (list []*streamtagparser.TagStreamData) {
+	for _, data := range tagsData {
+		if data == nil {
+			continue
+		}
+		if len(list) > 0 {
+			last := list[len(list)-1]
+			switch {
+			case last.Type == streamtagparser.TagStreamTypeText && data.Type == last.Type:
+				last.Text += data.Text
+				continue
+			case last.Type == streamtagparser.TagStreamTypeContent && data.Type == last.Type &&
+				last.TagName == data.TagName && last.ID == data.ID:
+				last.Content += data.Content
+				last.Accumulated = data.Accumulated
+				continue
+			case last.Type == streamtagparser.TagStreamTypeAttrDelta && data.Type == last.Type &&
+				last.Attrs[0].Name == data.Attrs[0].Name:
+				last.Attrs[0].Value += data.Attrs[0].Value
+				continue
+			}
+		}
+		clone := *data
+		clone.Attrs = slices.Clone(data.Attrs)
+		// sequence numbers count events, which depends on the chunking
+		clone.Seq = 0
+		list = append(list, &clone)
+	}
+	return
+}
+
+// CheckChunkInvariance parses input in one chunk and then with every chunking produced by the
+// splitters of this package, and fails t if any normalised event sequence differs
+func CheckChunkInvariance(t testing.TB, newParser func() Parser, input string) {
+	t.Helper()
+	expected := Normalize(Run(newParser(), []string{input}))
+
+	chunkings := append(SplitAdversarial(input), SplitEveryRune(input))
+	rnd := rand.New(rand.NewPCG(uint64(len(input)), 1))
+	for range 8 {
+		chunkings = append(chunkings, SplitRandom(input, rnd, 8))
+	}
+	for _, chunks := range chunkings {
+		actual := Normalize(Run(newParser(), chunks))
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("events depend on chunking\nchunks:   %q\nexpected: %s\nactual:   %s",
+				chunks, marshal(expected), marshal(actual))
+		}
+	}
+}
+
+// Fuzz registers a native fuzz target comparing the events of the input parsed in one chunk
+// with random chunkings of it
+func Fuzz(f *testing.F, newParser func() Parser, seeds ...string) {
+	for _, seed := range seeds {
+		f.Add(seed, uint64(0))
+	}
+	f.Fuzz(func(t *testing.T, input string, seed uint64) {
+		if !utf8.ValidString(input) {
+			t.Skip("chunks are split on rune boundaries")
+		}
+		expected := Normalize(Run(newParser(), []string{input}))
+		chunks := SplitRandom(input, rand.New(rand.NewPCG(seed, seed)), 1+int(seed%16))
+		actual := Normalize(Run(newParser(), chunks))
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("events depend on chunking\nchunks:   %q\nexpected: %s\nactual:   %s",
+				chunks, marshal(expected), marshal(actual))
+		}
+	})
+}
+
+func marshal(tagsData []*streamtagparser.TagStreamData) string {
+	b, _ := json.Marshal(tagsData)
+	return string(b)
+}
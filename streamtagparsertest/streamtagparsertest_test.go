@@ -0,0 +1,63 @@
+package streamtagparsertest
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/liushuangls/streamtagparser"
+)
+
+func TestSplitters(t *testing.T) {
+	s := "a你<b"
+	if chunks := SplitEveryRune(s); !reflect.DeepEqual(chunks, []string{"a", "你", "<", "b"}) {
+		t.Fatalf("unexpected chunks: %q", chunks)
+	}
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	if chunks := SplitRandom(s, rnd, 2); strings.Join(chunks, "") != s {
+		t.Fatalf("chunks do not add up to input: %q", chunks)
+	}
+
+	expected := [][]string{
+		{"a你", "</b>"},
+		{"a你<", "/b>"},
+		{"a你</", "b>"},
+		{"a你</b", ">"},
+		{"a你", "<", "/", "b", ">"},
+	}
+	if chunkings := SplitAdversarial("a你</b>"); !reflect.DeepEqual(chunkings, expected) {
+		t.Fatalf("unexpected chunkings: %q", chunkings)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tagsData := []*streamtagparser.TagStreamData{
+		streamtagparser.NewTextTagStreamData("a"),
+		streamtagparser.NewTextTagStreamData("b"),
+		streamtagparser.NewStartTagStreamData("Artifact", nil),
+		streamtagparser.NewContentTagStreamData("Artifact", "c"),
+		streamtagparser.NewContentTagStreamData("Artifact", "d"),
+	}
+	normalized := Normalize(tagsData)
+	if len(normalized) != 3 || normalized[0].Text != "ab" || normalized[2].Content != "cd" {
+		t.Fatalf("unexpected normalized events: %s", marshal(normalized))
+	}
+	if tagsData[0].Text != "a" {
+		t.Fatal("input was modified")
+	}
+}
+
+func TestCheckChunkInvariance(t *testing.T) {
+	newParser := func() Parser {
+		return streamtagparser.NewTagParser("Artifact", "Think")
+	}
+	for _, input := range []string{
+		`hello <Artifact id="1">local a=1</Artifact> world`,
+		"<Think><Artifact></Think> <<Artifact>>x</Artifact>></Arti",
+		"unterminated <Artifact a=1",
+	} {
+		CheckChunkInvariance(t, newParser, input)
+	}
+}
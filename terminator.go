@@ -0,0 +1,144 @@
+package streamtagparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxHeldAfterPlainEnd is how many bytes may follow a plain end tag in guarded content before
+// the tag is taken for closed there
+const maxHeldAfterPlainEnd = 500
+
+// WithEndNonce lets a start tag declare a nonce in the attribute attrName, the tag is then
+// only closed by an end tag carrying the nonce, e.g. <Artifact end="X7Q">...</Artifact X7Q>,
+// so that its content may contain </Artifact>.
+// The content following a plain end tag is held back, and released by the next plain end tag
+// or the nonce end tag. If a start tag of the same name, a blank line or more than 500 bytes
+// follow instead, or the stream ends or is flushed, the tag is closed at the plain end tag
+// and whatever followed is parsed again, so the content events always add up to the content
+// of the end event
+func WithEndNonce(attrName string) Option {
+	return func(p *TagParser) {
+		p.endNonceAttr = attrName
+	}
+}
+
+// WithContentLength lets a start tag declare the length in bytes of its content in the
+// attribute attrName, e.g. <Artifact length="12">, end tags that fit within that length are
+// content. A wrong length falls back like WithEndNonce
+func WithContentLength(attrName string) Option {
+	return func(p *TagParser) {
+		p.contentLengthAttr = attrName
+	}
+}
+
+// guardContent applies the nonce and content length declared in attrs to the current tag
+func (p *TagParser) guardContent(attrs []TagAttr) {
+	for _, attr := range attrs {
+		switch {
+		case p.endNonceAttr != "" && attr.Name == p.endNonceAttr && attr.Value != "":
+			p.currentEndTag = p.delimiters.EndOpen + p.currentTagName + " " + attr.Value +
+				p.delimiters.Close
+			p.guarded = true
+		case p.contentLengthAttr != "" && attr.Name == p.contentLengthAttr:
+			if n, err := strconv.Atoi(attr.Value); err == nil && n > 0 {
+				p.contentRemaining = n
+				p.guarded = true
+			}
+		}
+	}
+}
+
+// guardMarkers returns the markers of the guarded content besides the end tag that closes it:
+// a plain end tag, and a start tag of the same name that may follow it
+func (p *TagParser) guardMarkers() (markers []string) {
+	if !p.guarded {
+		return nil
+	}
+	if plain := p.delimiters.endTag(p.currentTagName); plain != p.currentEndTag {
+		markers = append(markers, plain)
+	}
+	return append(markers,
+		p.delimiters.startTag(p.currentTagName),
+		p.delimiters.startTagWithAttrs(p.currentTagName),
+	)
+}
+
+// guardedMarker handles marker in guarded content, ok is false for the markers that
+// contentMarker handles. withinLength tells whether the marker ends within the declared
+// content length
+func (p *TagParser) guardedMarker(
+	marker string,
+	withinLength bool,
+) (tags []*TagStreamData, ok bool) {
+	if !p.guarded || p.inCDATA {
+		return nil, false
+	}
+	plain := p.delimiters.endTag(p.currentTagName)
+	switch {
+	case marker == plain && (marker != p.currentEndTag || withinLength):
+		// the content up to here is what any fallback keeps, hold back what follows
+		if tail := p.entities.flush(); tail != "" {
+			tags = append(tags, p.appendContent(tail))
+		}
+		tags = append(tags, p.releaseHeld())
+		p.plainEndAt = p.tagContentBuffer.Len()
+		p.hasPlainEnd = true
+		p.heldRaw.Reset()
+		return append(tags, p.content(marker)), true
+	case marker == p.currentEndTag || marker == plain || marker == cdataOpen:
+		return nil, false
+	case !p.hasPlainEnd || withinLength:
+		return append(tags, p.content(marker)), true
+	}
+	// a start tag of the same name, the plain end tag closed the tag
+	return p.closeAtPlainEnd(), true
+}
+
+// holdsTooLong reports whether what followed the last plain end tag rules out a nonce end tag
+// or the end of the declared content length still coming
+func (p *TagParser) holdsTooLong() bool {
+	if !p.hasPlainEnd {
+		return false
+	}
+	held := p.heldRaw.String()
+	// a marker in the making is not counted
+	if len(held)-p.tagEndBuffer.Len() > maxHeldAfterPlainEnd {
+		return true
+	}
+	line, ok := strings.CutSuffix(held, "\n")
+	return ok && strings.HasSuffix(strings.TrimRight(line, " \t\r"), "\n")
+}
+
+// releaseHeld emits the content held back since the last plain end tag, which cannot close the
+// tag anymore
+func (p *TagParser) releaseHeld() *TagStreamData {
+	if !p.hasPlainEnd {
+		return nil
+	}
+	held := p.tagContentBuffer.String()[p.plainEndAt:]
+	p.hasPlainEnd = false
+	p.heldRaw.Reset()
+	if held == "" {
+		return nil
+	}
+	return NewContentTagStreamData(p.currentTagName, held)
+}
+
+// heldContent returns the number of bytes of content held back since the last plain end tag
+func (p *TagParser) heldContent() int {
+	if !p.hasPlainEnd {
+		return 0
+	}
+	return p.tagContentBuffer.Len() - p.plainEndAt
+}
+
+// closeAtPlainEnd closes a tag whose nonce end tag or content length never arrived at its last
+// plain end tag, and parses the raw text that followed it again
+func (p *TagParser) closeAtPlainEnd() []*TagStreamData {
+	content := p.tagContentBuffer.String()[:p.plainEndAt]
+	end := NewEndTagStreamData(p.currentTagName, p.currentAttrs, content)
+	rest := p.heldRaw.String()
+	p.initStatus()
+	return append([]*TagStreamData{end}, p.parse(rest)...)
+}
@@ -0,0 +1,213 @@
+package streamtagparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEndNonce(t *testing.T) {
+	newParser := func() *TagParser {
+		return NewTagParserWithOptions([]string{"Artifact"}, WithEndNonce("end"))
+	}
+	attrs := []TagAttr{{Name: "end", Value: "X7Q"}}
+
+	t.Run("normal", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact end="X7Q">a</Artifact>b</Artifact X7`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+					},
+				},
+				{
+					input: "Q>c",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "</Artifact>b"},
+						{
+							Type:    TagStreamTypeEnd,
+							TagName: "Artifact",
+							Attrs:   attrs,
+							Content: "a</Artifact>b",
+						},
+						{Type: TagStreamTypeText, Text: "c"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("forgotten nonce", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact end="X7Q">a</Artifact>b</Artifact> <Artifact>c`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a</Artifact>b"},
+						{
+							Type:    TagStreamTypeEnd,
+							TagName: "Artifact",
+							Attrs:   attrs,
+							Content: "a</Artifact>b",
+						},
+						{Type: TagStreamTypeText, Text: " "},
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "c"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "c"},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("forgotten nonce at the end of the stream", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact end="X7Q">a</Artifact> tail`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{Type: TagStreamTypeEnd, TagName: "Artifact", Attrs: attrs, Content: "a"},
+				{Type: TagStreamTypeText, Text: " tail"},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("blank line after a plain end tag", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: "<Artifact end=\"X7Q\">a</Artifact>\n \nDone",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Attrs: attrs, Content: "a"},
+						{Type: TagStreamTypeText, Text: "\n \nDone"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("long text after a plain end tag", func(t *testing.T) {
+		tail := strings.Repeat("x", maxHeldAfterPlainEnd+1)
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact end="X7Q">a</Artifact>` + tail + "y",
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Attrs: attrs, Content: "a"},
+						{Type: TagStreamTypeText, Text: tail + "y"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+
+	t.Run("escaped end tag", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact end="X7Q">a&lt;/Artifact>b`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a</Artifact>b"},
+					},
+				},
+			},
+			doneDatas: []*TagStreamData{
+				{
+					Type:    TagStreamTypeEnd,
+					TagName: "Artifact",
+					Attrs:   attrs,
+					Content: "a</Artifact>b",
+				},
+			},
+		}
+		parser := NewTagParserWithOptions(
+			[]string{"Artifact"},
+			WithEndNonce("end"),
+			WithDecodeContentEntities(),
+		)
+		testParser(t, testData, parser)
+	})
+
+	t.Run("without nonce", func(t *testing.T) {
+		testData := parserTest{
+			items: []parserTestItem{
+				{
+					input: `<Artifact>a</Artifact>`,
+					expectedTags: []*TagStreamData{
+						{Type: TagStreamTypeStart, TagName: "Artifact"},
+						{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+						{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "a"},
+					},
+				},
+			},
+		}
+		testParser(t, testData, newParser())
+	})
+}
+
+func TestContentLength(t *testing.T) {
+	newParser := func() *TagParser {
+		return NewTagParserWithOptions([]string{"Artifact"}, WithContentLength("length"))
+	}
+	attrs := []TagAttr{{Name: "length", Value: "13"}}
+
+	testData := parserTest{
+		items: []parserTestItem{
+			{
+				input: `<Artifact length="13">a</Artifact>b</Artifact>c`,
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeStart, TagName: "Artifact", Attrs: attrs},
+					{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a</Artifact>b"},
+					{
+						Type:    TagStreamTypeEnd,
+						TagName: "Artifact",
+						Attrs:   attrs,
+						Content: "a</Artifact>b",
+					},
+					{Type: TagStreamTypeText, Text: "c"},
+				},
+			},
+			{
+				input: `<Artifact length="30">a</Artifact>bc`,
+				expectedTags: []*TagStreamData{
+					{
+						Type:    TagStreamTypeStart,
+						TagName: "Artifact",
+						Attrs:   []TagAttr{{Name: "length", Value: "30"}},
+					},
+					{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+				},
+			},
+		},
+		doneDatas: []*TagStreamData{
+			{
+				Type:    TagStreamTypeEnd,
+				TagName: "Artifact",
+				Attrs:   []TagAttr{{Name: "length", Value: "30"}},
+				Content: "a",
+			},
+			{Type: TagStreamTypeText, Text: "bc"},
+		},
+	}
+	testParser(t, testData, newParser())
+}
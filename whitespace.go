@@ -0,0 +1,116 @@
+package streamtagparser
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithCollapseWhitespace drops the whitespace that starts the text after a tag when the text
+// before the tag already ended in whitespace, or when the tag started the stream, so that
+// extracted tags do not leave blank gaps, e.g. "Here:\n\n<Artifact>...</Artifact>\n\nDone"
+// becomes the text "Here:\n\nDone". The whitespace may arrive in any number of chunks
+func WithCollapseWhitespace() Option {
+	return func(p *TagParser) {
+		p.collapseWhitespace = true
+	}
+}
+
+// WithTrimContent drops the newline that starts the content of a tag and the newline that
+// ends it, from the content events as well as the end event, e.g. the content of
+// "<Artifact>\nx\n</Artifact>" is "x". A newline at the end of a content event is held back
+// until it is known whether the tag ends after it. Code blocks are left alone
+func WithTrimContent() Option {
+	return func(p *TagParser) {
+		p.trimContent = true
+	}
+}
+
+// whitespaceState tracks the edges of text and content for WithCollapseWhitespace and
+// WithTrimContent
+type whitespaceState struct {
+	textOpen   bool // the text emitted last ends in a non-space rune
+	collapsing bool // drop whitespace starting the text
+	trimStart  bool // drop a newline starting the content
+	held       string
+}
+
+func (p *TagParser) normalizeWhitespace(tagsData []*TagStreamData) []*TagStreamData {
+	if !p.collapseWhitespace && !p.trimContent {
+		return tagsData
+	}
+	w := &p.whitespace
+	list := make([]*TagStreamData, 0, len(tagsData))
+	for _, data := range tagsData {
+		trim := p.trimContent && data.TagName != p.codeBlockTag
+		switch data.Type {
+		case TagStreamTypeText:
+			if w.collapsing {
+				data.Text = strings.TrimLeftFunc(data.Text, unicode.IsSpace)
+				if data.Text == "" {
+					continue
+				}
+				w.collapsing = false
+			}
+			r, _ := utf8.DecodeLastRuneInString(data.Text)
+			w.textOpen = !unicode.IsSpace(r)
+		case TagStreamTypeStart:
+			w.trimStart = trim
+			w.held = ""
+		case TagStreamTypeContent:
+			if !trim {
+				break
+			}
+			if data.Content = w.trimContent(data.Content); data.Content == "" {
+				continue
+			}
+		case TagStreamTypeEnd:
+			w.collapsing = p.collapseWhitespace && !w.textOpen
+			if !trim {
+				break
+			}
+			if w.held == "\r" {
+				list = append(list, NewContentTagStreamData(data.TagName, w.held))
+			}
+			w.held = ""
+			data.Content = trimNewlineSuffix(trimNewlinePrefix(data.Content))
+		}
+		list = append(list, data)
+	}
+	return list
+}
+
+// trimContent returns what of content can be emitted
+func (w *whitespaceState) trimContent(content string) string {
+	s := w.held + content
+	w.held = ""
+	if w.trimStart {
+		if s == "\r" {
+			w.held = s
+			return ""
+		}
+		s = trimNewlinePrefix(s)
+		w.trimStart = false
+	}
+	switch {
+	case strings.HasSuffix(s, "\r\n"):
+		w.held = "\r\n"
+	case strings.HasSuffix(s, "\n"), strings.HasSuffix(s, "\r"):
+		w.held = s[len(s)-1:]
+	}
+	return s[:len(s)-len(w.held)]
+}
+
+func trimNewlinePrefix(s string) string {
+	if s, ok := strings.CutPrefix(s, "\r\n"); ok {
+		return s
+	}
+	return strings.TrimPrefix(s, "\n")
+}
+
+func trimNewlineSuffix(s string) string {
+	if s, ok := strings.CutSuffix(s, "\r\n"); ok {
+		return s
+	}
+	return strings.TrimSuffix(s, "\n")
+}
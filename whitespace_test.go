@@ -0,0 +1,74 @@
+package streamtagparser
+
+import "testing"
+
+func TestCollapseWhitespace(t *testing.T) {
+	testData := parserTest{
+		items: []parserTestItem{
+			{
+				input: "Here:\n\n<Artifact>a</Artifact>\n",
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeText, Text: "Here:\n\n"},
+					{Type: TagStreamTypeStart, TagName: "Artifact"},
+					{Type: TagStreamTypeContent, TagName: "Artifact", Content: "a"},
+					{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "a"},
+				},
+			},
+			{
+				input:        " \n",
+				expectedTags: []*TagStreamData{},
+			},
+			{
+				input: "Done <Artifact></Artifact> now",
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeText, Text: "Done "},
+					{Type: TagStreamTypeStart, TagName: "Artifact"},
+					{Type: TagStreamTypeEnd, TagName: "Artifact"},
+					{Type: TagStreamTypeText, Text: "now"},
+				},
+			},
+			{
+				input: "!<Artifact></Artifact>\nok",
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeText, Text: "!"},
+					{Type: TagStreamTypeStart, TagName: "Artifact"},
+					{Type: TagStreamTypeEnd, TagName: "Artifact"},
+					{Type: TagStreamTypeText, Text: "\nok"},
+				},
+			},
+		},
+	}
+	testParser(t, testData, NewTagParserWithOptions([]string{"Artifact"}, WithCollapseWhitespace()))
+}
+
+func TestTrimContent(t *testing.T) {
+	testData := parserTest{
+		items: []parserTestItem{
+			{
+				input: "<Artifact>\r",
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeStart, TagName: "Artifact"},
+				},
+			},
+			{
+				input: "\nx\n",
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeContent, TagName: "Artifact", Content: "x"},
+				},
+			},
+			{
+				input: "y\n",
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeContent, TagName: "Artifact", Content: "\ny"},
+				},
+			},
+			{
+				input: "</Artifact>",
+				expectedTags: []*TagStreamData{
+					{Type: TagStreamTypeEnd, TagName: "Artifact", Content: "x\ny"},
+				},
+			},
+		},
+	}
+	testParser(t, testData, NewTagParserWithOptions([]string{"Artifact"}, WithTrimContent()))
+}